@@ -0,0 +1,345 @@
+package archive
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/gorm"
+)
+
+// Formats comments are exported to and imported from
+const (
+	FormatJSONL = "jsonl"
+	FormatCSV   = "csv"
+)
+
+// Columns of the CSV format, in the order they are written
+var csvColumns = []string{"id", "modId", "userId", "text", "status", "hidden", "createdAt", "updatedAt", "deletedAt"}
+
+// Columns a CSV file must have, the others may be left out
+var csvRequired = []string{"modId", "userId", "text", "createdAt"}
+
+// A comment as it is exported and imported, the id is generated when empty
+type Record struct {
+	ID        string     `json:"id"`
+	ModID     string     `json:"modId"`
+	UserID    string     `json:"userId"`
+	Text      string     `json:"text"`
+	Status    string     `json:"status,omitempty"`
+	Hidden    bool       `json:"hidden,omitempty"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+func RecordFromComment(comment *models.Comment) Record {
+	record := Record{
+		ID:        comment.ID,
+		ModID:     comment.ModID,
+		UserID:    comment.UserID,
+		Text:      comment.Text,
+		Status:    comment.Status,
+		Hidden:    comment.Hidden,
+		CreatedAt: comment.CreatedAt,
+		UpdatedAt: comment.UpdatedAt,
+	}
+	if record.UpdatedAt.IsZero() {
+		record.UpdatedAt = record.CreatedAt
+	}
+	if comment.DeletedAt.Valid {
+		record.DeletedAt = &comment.DeletedAt.Time
+	}
+	return record
+}
+
+func (r Record) Comment() *models.Comment {
+	comment := &models.Comment{
+		ID:        r.ID,
+		ModID:     r.ModID,
+		UserID:    r.UserID,
+		Text:      r.Text,
+		Status:    r.Status,
+		Hidden:    r.Hidden,
+		CreatedAt: r.CreatedAt,
+		UpdatedAt: r.UpdatedAt,
+	}
+	if r.DeletedAt != nil {
+		comment.DeletedAt = gorm.DeletedAt{Time: *r.DeletedAt, Valid: true}
+	}
+	return comment
+}
+
+func RecordFromProto(comment *protobuffer.ImportedComment) Record {
+	record := Record{
+		ID:     comment.ID,
+		ModID:  comment.ModID,
+		UserID: comment.UserID,
+		Text:   comment.Text,
+		Status: models.StatusFromProto(comment.Status),
+		Hidden: comment.Hidden,
+	}
+	if comment.Create_At != nil {
+		record.CreatedAt = comment.Create_At.AsTime()
+	}
+	if comment.Update_At != nil {
+		record.UpdatedAt = comment.Update_At.AsTime()
+	}
+	if comment.Delete_At != nil {
+		deletedAt := comment.Delete_At.AsTime()
+		record.DeletedAt = &deletedAt
+	}
+	return record
+}
+
+func RecordToProto(record Record) *protobuffer.ImportedComment {
+	comment := &protobuffer.ImportedComment{
+		ID:     record.ID,
+		ModID:  record.ModID,
+		UserID: record.UserID,
+		Text:   record.Text,
+		Status: models.StatusToProto(record.Status),
+		Hidden: record.Hidden,
+	}
+	if !record.CreatedAt.IsZero() {
+		comment.Create_At = timestamppb.New(record.CreatedAt)
+	}
+	if !record.UpdatedAt.IsZero() {
+		comment.Update_At = timestamppb.New(record.UpdatedAt)
+	}
+	if record.DeletedAt != nil {
+		comment.Delete_At = timestamppb.New(*record.DeletedAt)
+	}
+	return comment
+}
+
+// A row that could not be decoded, reading continues with the next row
+type RecordError struct {
+	Line int
+	Err  error
+}
+
+func (e *RecordError) Error() string {
+	return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+}
+
+func (e *RecordError) Unwrap() error {
+	return e.Err
+}
+
+type Reader interface {
+	// Returns the next record, a *RecordError for a row that cannot be decoded and io.EOF after the last row
+	Read() (Record, error)
+	// Returns the line of the last record read
+	Line() int
+}
+
+type Writer interface {
+	Write(record Record) error
+	Flush() error
+}
+
+func NewReader(format string, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatJSONL:
+		return &jsonlReader{r: bufio.NewReader(r)}, nil
+	case FormatCSV:
+		return newCSVReader(r)
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected: %s, %s", format, FormatJSONL, FormatCSV)
+	}
+}
+
+func NewWriter(format string, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatJSONL:
+		buffered := bufio.NewWriter(w)
+		return &jsonlWriter{w: buffered, enc: json.NewEncoder(buffered)}, nil
+	case FormatCSV:
+		return &csvWriter{w: csv.NewWriter(w)}, nil
+	default:
+		return nil, fmt.Errorf("unknown format %q, expected: %s, %s", format, FormatJSONL, FormatCSV)
+	}
+}
+
+// One JSON object per line, blank lines are skipped
+type jsonlReader struct {
+	r    *bufio.Reader
+	line int
+}
+
+func (r *jsonlReader) Read() (Record, error) {
+	for {
+		data, err := r.r.ReadBytes('\n')
+		if len(data) == 0 && err != nil {
+			return Record{}, err
+		}
+		r.line++
+		if data = bytes.TrimSpace(data); len(data) == 0 {
+			continue
+		}
+		var record Record
+		if err := json.Unmarshal(data, &record); err != nil {
+			return Record{}, &RecordError{Line: r.line, Err: err}
+		}
+		return record, nil
+	}
+}
+
+func (r *jsonlReader) Line() int {
+	return r.line
+}
+
+type jsonlWriter struct {
+	w   *bufio.Writer
+	enc *json.Encoder
+}
+
+func (w *jsonlWriter) Write(record Record) error {
+	return w.enc.Encode(record)
+}
+
+func (w *jsonlWriter) Flush() error {
+	return w.w.Flush()
+}
+
+// A header row naming the columns, followed by a row per record
+type csvReader struct {
+	r       *csv.Reader
+	columns map[string]int
+	fields  int
+	line    int
+}
+
+func newCSVReader(r io.Reader) (*csvReader, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	columns := make(map[string]int, len(header))
+	for i, name := range header {
+		columns[name] = i
+	}
+	for _, name := range csvRequired {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header misses column %q", name)
+		}
+	}
+	return &csvReader{r: reader, columns: columns, fields: len(header), line: 1}, nil
+}
+
+func (r *csvReader) Read() (Record, error) {
+	row, err := r.r.Read()
+	if err == io.EOF {
+		return Record{}, err
+	}
+	var parseErr *csv.ParseError
+	if errors.As(err, &parseErr) {
+		r.line = parseErr.StartLine
+		return Record{}, &RecordError{Line: r.line, Err: parseErr.Err}
+	}
+	if err != nil {
+		return Record{}, err
+	}
+	r.line, _ = r.r.FieldPos(0)
+	if len(row) != r.fields {
+		return Record{}, &RecordError{Line: r.line, Err: fmt.Errorf("expected %d fields, got %d", r.fields, len(row))}
+	}
+
+	record := Record{ID: r.field(row, "id"), ModID: r.field(row, "modId"), UserID: r.field(row, "userId"), Text: r.field(row, "text"), Status: r.field(row, "status")}
+	if value := r.field(row, "hidden"); value != "" {
+		if record.Hidden, err = strconv.ParseBool(value); err != nil {
+			return Record{}, &RecordError{Line: r.line, Err: fmt.Errorf("invalid hidden: %w", err)}
+		}
+	}
+	if record.CreatedAt, err = r.time(row, "createdAt"); err != nil {
+		return Record{}, err
+	}
+	if record.UpdatedAt, err = r.time(row, "updatedAt"); err != nil {
+		return Record{}, err
+	}
+	deletedAt, err := r.time(row, "deletedAt")
+	if err != nil {
+		return Record{}, err
+	}
+	if !deletedAt.IsZero() {
+		record.DeletedAt = &deletedAt
+	}
+	return record, nil
+}
+
+func (r *csvReader) Line() int {
+	return r.line
+}
+
+func (r *csvReader) field(row []string, name string) string {
+	if i, ok := r.columns[name]; ok {
+		return row[i]
+	}
+	return ""
+}
+
+// Parses an RFC 3339 time, an empty field is the zero time
+func (r *csvReader) time(row []string, name string) (time.Time, error) {
+	value := r.field(row, name)
+	if value == "" {
+		return time.Time{}, nil
+	}
+	parsed, err := time.Parse(time.RFC3339Nano, value)
+	if err != nil {
+		return time.Time{}, &RecordError{Line: r.line, Err: fmt.Errorf("invalid %s: %w", name, err)}
+	}
+	return parsed, nil
+}
+
+type csvWriter struct {
+	w      *csv.Writer
+	header bool
+}
+
+func (w *csvWriter) Write(record Record) error {
+	if !w.header {
+		w.header = true
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	deletedAt := ""
+	if record.DeletedAt != nil {
+		deletedAt = record.DeletedAt.UTC().Format(time.RFC3339Nano)
+	}
+	return w.w.Write([]string{
+		record.ID,
+		record.ModID,
+		record.UserID,
+		record.Text,
+		record.Status,
+		strconv.FormatBool(record.Hidden),
+		record.CreatedAt.UTC().Format(time.RFC3339Nano),
+		record.UpdatedAt.UTC().Format(time.RFC3339Nano),
+		deletedAt,
+	})
+}
+
+// Writes the header of an empty export too, so it can be imported
+func (w *csvWriter) Flush() error {
+	if !w.header {
+		w.header = true
+		if err := w.w.Write(csvColumns); err != nil {
+			return err
+		}
+	}
+	w.w.Flush()
+	return w.w.Error()
+}
@@ -0,0 +1,108 @@
+package archive
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func readAll(t *testing.T, r Reader) ([]Record, []*RecordError) {
+	var records []Record
+	var rejected []*RecordError
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return records, rejected
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			rejected = append(rejected, recordErr)
+			continue
+		}
+		assert.NoError(t, err)
+		records = append(records, record)
+	}
+}
+
+// will test records survive a round trip through every format
+func TestRoundTrip(t *testing.T) {
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	deleted := created.Add(time.Hour)
+	records := []Record{
+		{ID: "a", ModID: "mod", UserID: "user", Text: "line one\nline \"two\", three", Status: "published", CreatedAt: created, UpdatedAt: created},
+		{ID: "b", ModID: "mod", UserID: "user", Text: "gone", Status: "rejected", Hidden: true, CreatedAt: created, UpdatedAt: deleted, DeletedAt: &deleted},
+	}
+
+	for _, format := range []string{FormatJSONL, FormatCSV} {
+		t.Run(format, func(t *testing.T) {
+			// Arrange
+			var buffer bytes.Buffer
+			writer, err := NewWriter(format, &buffer)
+			assert.NoError(t, err)
+			for _, record := range records {
+				assert.NoError(t, writer.Write(record))
+			}
+			assert.NoError(t, writer.Flush())
+
+			// Act
+			reader, err := NewReader(format, &buffer)
+			assert.NoError(t, err)
+			result, rejected := readAll(t, reader)
+
+			// Assert
+			assert.Empty(t, rejected)
+			assert.Equal(t, records, result)
+		})
+	}
+}
+
+// will test a malformed line is reported with its line number and reading continues
+func TestJSONLMalformedLine(t *testing.T) {
+	// Arrange
+	input := `{"id":"a","text":"one"}` + "\n\n" + `{"id":` + "\n" + `{"id":"b","text":"two"}`
+	reader, _ := NewReader(FormatJSONL, strings.NewReader(input))
+
+	// Act
+	records, rejected := readAll(t, reader)
+
+	// Assert
+	assert.Len(t, records, 2)
+	assert.Equal(t, 4, reader.Line())
+	assert.Len(t, rejected, 1)
+	assert.Equal(t, 3, rejected[0].Line)
+}
+
+// will test csv columns are found by their header and invalid rows are reported
+func TestCSVColumns(t *testing.T) {
+	// Arrange
+	input := "text,userId,modId,createdAt\n" +
+		"nice,user,mod,2021-03-01T12:00:00Z\n" +
+		"nice,user,mod,yesterday\n" +
+		"nice,user\n"
+	reader, err := NewReader(FormatCSV, strings.NewReader(input))
+	assert.NoError(t, err)
+
+	// Act
+	records, rejected := readAll(t, reader)
+
+	// Assert
+	assert.Len(t, records, 1)
+	assert.Equal(t, Record{ModID: "mod", UserID: "user", Text: "nice", CreatedAt: time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)}, records[0])
+	assert.Len(t, rejected, 2)
+	assert.Equal(t, 3, rejected[0].Line)
+	assert.Equal(t, 4, rejected[1].Line)
+}
+
+// will test a csv header without a required column
+func TestCSVMissingColumn(t *testing.T) {
+	// Act
+	_, err := NewReader(FormatCSV, strings.NewReader("id,text\n"))
+
+	// Assert
+	assert.EqualError(t, err, `csv header misses column "modId"`)
+}
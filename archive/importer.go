@@ -0,0 +1,205 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/go-playground/validator/v10"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+)
+
+// How an imported comment whose id exists is handled
+const (
+	ConflictSkip      = "skip"
+	ConflictOverwrite = "overwrite"
+)
+
+// Bounds of the number of comments inserted per transaction
+const (
+	DefaultBatchSize = 500
+	MaxBatchSize     = 1000
+)
+
+// Where imported comments are inserted
+type Store interface {
+	// Inserts comments in one transaction and returns the ids that existed, which are skipped or overwritten
+	ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error)
+}
+
+// Store that can insert a batch and append its audit entries in one transaction
+type AuditStore interface {
+	Store
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Audit(ctx context.Context, entries ...*models.AuditLog) error
+}
+
+// Store that records every comment an import creates or overwrites in the audit log, in the transaction of its batch
+type auditedStore struct {
+	store AuditStore
+	entry models.AuditLog
+}
+
+// Returns a store that audits the comments it changes. Entries are copies of entry with the comment as target and after snapshot.
+func NewAuditedStore(store AuditStore, entry models.AuditLog) Store {
+	return &auditedStore{store: store, entry: entry}
+}
+
+func (s *auditedStore) ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error) {
+	var existing []string
+	err := s.store.Transaction(ctx, func(ctx context.Context) error {
+		var err error
+		if existing, err = s.store.ImportComments(ctx, comments, overwrite); err != nil {
+			return err
+		}
+
+		skipped := make(map[string]bool, len(existing))
+		if !overwrite {
+			for _, id := range existing {
+				skipped[id] = true
+			}
+		}
+		entries := make([]*models.AuditLog, 0, len(comments))
+		for _, comment := range comments {
+			if skipped[comment.ID] {
+				continue
+			}
+			entry := s.entry
+			entry.Target = comment.ID
+			if entry.After, err = models.AuditSnapshot(comment); err != nil {
+				return err
+			}
+			entries = append(entries, &entry)
+		}
+		if len(entries) == 0 {
+			return nil
+		}
+		return s.store.Audit(ctx, entries...)
+	})
+	return existing, err
+}
+
+// A record that was not imported
+type Rejection struct {
+	Line   int    `json:"line"`
+	ID     string `json:"id,omitempty"`
+	Reason string `json:"reason"`
+}
+
+type Report struct {
+	Read     int         `json:"read"`
+	Created  int         `json:"created"`
+	Updated  int         `json:"updated"`
+	Skipped  int         `json:"skipped"`
+	Rejected []Rejection `json:"rejected"`
+}
+
+// Validates records by the rules of CreateComment and inserts them in batches
+type Importer struct {
+	store     Store
+	validate  *validator.Validate
+	batchSize int
+	overwrite bool
+}
+
+func NewImporter(store Store, validate *validator.Validate, batchSize int, conflict string) (*Importer, error) {
+	if batchSize < 1 || batchSize > MaxBatchSize {
+		return nil, fmt.Errorf("invalid batch size %d, expected 1-%d", batchSize, MaxBatchSize)
+	}
+	if conflict != ConflictSkip && conflict != ConflictOverwrite {
+		return nil, fmt.Errorf("unknown conflict handling %q, expected: %s, %s", conflict, ConflictSkip, ConflictOverwrite)
+	}
+	return &Importer{store: store, validate: validate, batchSize: batchSize, overwrite: conflict == ConflictOverwrite}, nil
+}
+
+// Imports every record of r. Invalid records are rejected and reported, a failing batch stops the import
+// with the batches before it inserted.
+func (i *Importer) Import(ctx context.Context, r Reader) (*Report, error) {
+	report := &Report{Rejected: []Rejection{}}
+	batch := make([]*models.Comment, 0, i.batchSize)
+	lines := make(map[string]int, i.batchSize)
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var recordErr *RecordError
+		if errors.As(err, &recordErr) {
+			report.Read++
+			report.Rejected = append(report.Rejected, Rejection{Line: recordErr.Line, Reason: recordErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Read++
+
+		comment, err := i.comment(record)
+		if err == nil {
+			if line, ok := lines[comment.ID]; ok {
+				err = fmt.Errorf("id repeats the comment on line %d", line)
+			}
+		}
+		if err != nil {
+			report.Rejected = append(report.Rejected, Rejection{Line: r.Line(), ID: record.ID, Reason: err.Error()})
+			continue
+		}
+		batch = append(batch, comment)
+		lines[comment.ID] = r.Line()
+
+		if len(batch) == i.batchSize {
+			if err := i.insert(ctx, batch, report); err != nil {
+				return report, err
+			}
+			batch = batch[:0]
+			lines = make(map[string]int, i.batchSize)
+		}
+	}
+	if len(batch) > 0 {
+		if err := i.insert(ctx, batch, report); err != nil {
+			return report, err
+		}
+	}
+	return report, nil
+}
+
+func (i *Importer) insert(ctx context.Context, batch []*models.Comment, report *Report) error {
+	existing, err := i.store.ImportComments(ctx, batch, i.overwrite)
+	if err != nil {
+		return err
+	}
+	report.Created += len(batch) - len(existing)
+	if i.overwrite {
+		report.Updated += len(existing)
+	} else {
+		report.Skipped += len(existing)
+	}
+	return nil
+}
+
+// Returns the comment of a record, keeping its id and timestamps
+func (i *Importer) comment(record Record) (*models.Comment, error) {
+	comment := record.Comment()
+	comment.Text = models.NormalizeText(comment.Text)
+	if comment.ID == "" {
+		comment.ID = uuid.NewString()
+	}
+	if comment.Status == "" {
+		comment.Status = models.StatusPublished
+	}
+	if comment.Status != models.StatusPublished && comment.Status != models.StatusPending && comment.Status != models.StatusRejected {
+		return nil, fmt.Errorf("unknown status %q", comment.Status)
+	}
+	if comment.CreatedAt.IsZero() {
+		return nil, errors.New("missing createdAt")
+	}
+	if comment.UpdatedAt.IsZero() {
+		comment.UpdatedAt = comment.CreatedAt
+	}
+	if err := i.validate.Struct(comment); err != nil {
+		return nil, err
+	}
+	return comment, nil
+}
@@ -0,0 +1,150 @@
+package archive
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeStore struct {
+	batches  [][]*models.Comment
+	existing map[string]bool
+	err      error
+}
+
+func (s *fakeStore) ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	s.batches = append(s.batches, comments)
+	var existing []string
+	for _, comment := range comments {
+		if s.existing[comment.ID] {
+			existing = append(existing, comment.ID)
+		}
+	}
+	return existing, nil
+}
+
+func jsonl(lines ...string) Reader {
+	reader, _ := NewReader(FormatJSONL, strings.NewReader(strings.Join(lines, "\n")))
+	return reader
+}
+
+// will test records are validated by the rules of CreateComment and rejected with their line
+func TestImportRejections(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	store := &fakeStore{}
+	importer, _ := NewImporter(store, models.NewValidator(models.DefaultTextLimits()), DefaultBatchSize, ConflictSkip)
+	reader := jsonl(
+		`{"modId":"`+modID+`","userId":"user","text":"  nice track  ","createdAt":"2021-03-01T12:00:00Z"}`,
+		`{"modId":"`+modID+`","userId":"user","text":"   ","createdAt":"2021-03-01T12:00:00Z"}`,
+		`{"modId":"`+modID+`","userId":"user","text":"nice"}`,
+		`{"modId":"`+modID+`","userId":"user","text":"nice","status":"spam","createdAt":"2021-03-01T12:00:00Z"}`,
+		`{"id":"42","modId":"`+modID+`","userId":"user","text":"nice","createdAt":"2021-03-01T12:00:00Z"}`,
+		`{"modId":`,
+	)
+
+	// Act
+	report, err := importer.Import(context.Background(), reader)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 6, report.Read)
+	assert.Equal(t, 1, report.Created)
+	assert.Len(t, report.Rejected, 5)
+	assert.Equal(t, []int{2, 3, 4, 5, 6}, []int{report.Rejected[0].Line, report.Rejected[1].Line, report.Rejected[2].Line, report.Rejected[3].Line, report.Rejected[4].Line})
+	assert.Equal(t, "missing createdAt", report.Rejected[1].Reason)
+	assert.Equal(t, `unknown status "spam"`, report.Rejected[2].Reason)
+	assert.Equal(t, "42", report.Rejected[3].ID)
+
+	imported := store.batches[0][0]
+	assert.Equal(t, "nice track", imported.Text)
+	assert.Equal(t, models.StatusPublished, imported.Status)
+	assert.Equal(t, imported.CreatedAt, imported.UpdatedAt)
+	assert.NotEmpty(t, imported.ID)
+}
+
+// will test records are inserted in batches and existing ids are counted by the conflict handling
+func TestImportBatches(t *testing.T) {
+	for _, conflict := range []string{ConflictSkip, ConflictOverwrite} {
+		t.Run(conflict, func(t *testing.T) {
+			// Arrange
+			modID := uuid.NewString()
+			existing := uuid.NewString()
+			store := &fakeStore{existing: map[string]bool{existing: true}}
+			importer, _ := NewImporter(store, models.NewValidator(models.DefaultTextLimits()), 2, conflict)
+			reader := jsonl(
+				`{"id":"`+existing+`","modId":"`+modID+`","userId":"user","text":"one","createdAt":"2021-03-01T12:00:00Z"}`,
+				`{"modId":"`+modID+`","userId":"user","text":"two","createdAt":"2021-03-01T12:00:00Z"}`,
+				`{"modId":"`+modID+`","userId":"user","text":"three","createdAt":"2021-03-01T12:00:00Z"}`,
+			)
+
+			// Act
+			report, err := importer.Import(context.Background(), reader)
+
+			// Assert
+			assert.NoError(t, err)
+			assert.Len(t, store.batches, 2)
+			assert.Equal(t, 2, report.Created)
+			if conflict == ConflictSkip {
+				assert.Equal(t, 1, report.Skipped)
+			} else {
+				assert.Equal(t, 1, report.Updated)
+			}
+		})
+	}
+}
+
+// will test an id that repeats within a batch is rejected
+func TestImportRepeatedID(t *testing.T) {
+	// Arrange
+	id := uuid.NewString()
+	modID := uuid.NewString()
+	store := &fakeStore{}
+	importer, _ := NewImporter(store, models.NewValidator(models.DefaultTextLimits()), DefaultBatchSize, ConflictSkip)
+	reader := jsonl(
+		`{"id":"`+id+`","modId":"`+modID+`","userId":"user","text":"one","createdAt":"2021-03-01T12:00:00Z"}`,
+		`{"id":"`+id+`","modId":"`+modID+`","userId":"user","text":"two","createdAt":"2021-03-01T12:00:00Z"}`,
+	)
+
+	// Act
+	report, err := importer.Import(context.Background(), reader)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, 1, report.Created)
+	assert.Equal(t, []Rejection{{Line: 2, ID: id, Reason: "id repeats the comment on line 1"}}, report.Rejected)
+}
+
+// will test a failing batch stops the import
+func TestImportStoreError(t *testing.T) {
+	// Arrange
+	store := &fakeStore{err: errors.New("connection reset")}
+	importer, _ := NewImporter(store, models.NewValidator(models.DefaultTextLimits()), DefaultBatchSize, ConflictSkip)
+	reader := jsonl(`{"modId":"` + uuid.NewString() + `","userId":"user","text":"one","createdAt":"2021-03-01T12:00:00Z"}`)
+
+	// Act
+	report, err := importer.Import(context.Background(), reader)
+
+	// Assert
+	assert.EqualError(t, err, "connection reset")
+	assert.Equal(t, 0, report.Created)
+}
+
+// will test invalid importer settings
+func TestNewImporterInvalid(t *testing.T) {
+	// Act
+	_, batchErr := NewImporter(&fakeStore{}, nil, MaxBatchSize+1, ConflictSkip)
+	_, conflictErr := NewImporter(&fakeStore{}, nil, DefaultBatchSize, "merge")
+
+	// Assert
+	assert.Error(t, batchErr)
+	assert.Error(t, conflictErr)
+}
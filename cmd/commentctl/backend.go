@@ -0,0 +1,149 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+)
+
+// Returned by backends for commands they cannot run
+var errUnsupported = errors.New("command needs -db, the gRPC API does not support it")
+
+// Where commands are run, the database directly or the gRPC API
+type backend interface {
+	List(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error)
+	Export(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error
+	Import(ctx context.Context, r archive.Reader, options importOptions) (*archive.Report, error)
+	Delete(ctx context.Context, id string) error
+	Restore(ctx context.Context, id string) error
+	Purge(ctx context.Context, before time.Time) (comments int64, keys int64, err error)
+	Stats(ctx context.Context) (*models.CommentStats, error)
+	Migrate(ctx context.Context) error
+	AuditLog(ctx context.Context, filter models.AuditFilter, limit int) ([]*models.AuditLog, error)
+	VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error)
+}
+
+// How an import validates and inserts comments
+type importOptions struct {
+	batchSize int
+	conflict  string
+	limits    models.TextLimits
+}
+
+// Runs commands against the database, bypassing the cache of running services. Changes are audited as actor.
+type dbBackend struct {
+	repo  repository.ModRepository
+	actor string
+}
+
+func (b *dbBackend) List(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error) {
+	return b.repo.SearchByFilter(ctx, filter, limit)
+}
+
+func (b *dbBackend) Export(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error {
+	return b.repo.StreamComments(ctx, filter, fn)
+}
+
+// Validates comments by the rules of CreateComment, keeping their ids and timestamps
+func (b *dbBackend) Import(ctx context.Context, r archive.Reader, options importOptions) (*archive.Report, error) {
+	store := archive.NewAuditedStore(b.repo, models.AuditLog{
+		Action: models.AuditActionImport,
+		Actor:  b.actor,
+		Detail: fmt.Sprintf("conflict=%s", options.conflict),
+	})
+	importer, err := archive.NewImporter(store, models.NewValidator(options.limits), options.batchSize, options.conflict)
+	if err != nil {
+		return nil, err
+	}
+
+	report, importErr := importer.Import(ctx, r)
+	detail := fmt.Sprintf("conflict=%s received=%d created=%d updated=%d skipped=%d rejected=%d", options.conflict, report.Read, report.Created, report.Updated, report.Skipped, len(report.Rejected))
+	if importErr != nil {
+		detail += fmt.Sprintf(" error=%q", importErr.Error())
+	}
+	err = b.repo.Audit(ctx, &models.AuditLog{Action: models.AuditActionImport, Actor: b.actor, Detail: detail})
+	if importErr != nil {
+		return report, importErr
+	}
+	return report, err
+}
+
+func (b *dbBackend) Delete(ctx context.Context, id string) error {
+	return b.repo.Transaction(ctx, func(ctx context.Context) error {
+		comment, err := b.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		if err := b.repo.Delete(ctx, id); err != nil {
+			return err
+		}
+		return b.audit(ctx, &models.AuditLog{Action: models.AuditActionDelete, Target: id}, comment, nil)
+	})
+}
+
+func (b *dbBackend) Restore(ctx context.Context, id string) error {
+	return b.repo.Transaction(ctx, func(ctx context.Context) error {
+		if err := b.repo.Restore(ctx, id); err != nil {
+			return err
+		}
+		comment, err := b.repo.FindByID(ctx, id)
+		if err != nil {
+			return err
+		}
+		return b.audit(ctx, &models.AuditLog{Action: models.AuditActionRestore, Target: id}, nil, comment)
+	})
+}
+
+// Hard deletes comments deleted before a time along with every expired idempotency key. Every batch
+// commits on its own, the purge is audited after them with the error that stopped it.
+func (b *dbBackend) Purge(ctx context.Context, before time.Time) (int64, int64, error) {
+	comments, purgeErr := b.repo.PurgeDeleted(ctx, before)
+	detail := fmt.Sprintf("before=%s purged=%d", before.UTC().Format(time.RFC3339), comments)
+	if purgeErr != nil {
+		detail += fmt.Sprintf(" error=%q", purgeErr.Error())
+	}
+	err := b.audit(ctx, &models.AuditLog{Action: models.AuditActionPurge, Detail: detail}, nil, nil)
+	if purgeErr != nil {
+		return comments, 0, purgeErr
+	}
+	if err != nil {
+		return comments, 0, err
+	}
+	keys, err := b.repo.PurgeIdempotencyKeys(ctx, time.Now())
+	return comments, keys, err
+}
+
+// Appends an entry for a change made as the actor, with snapshots of the comment before and after it
+func (b *dbBackend) audit(ctx context.Context, entry *models.AuditLog, before *models.Comment, after *models.Comment) error {
+	entry.Actor = b.actor
+	var err error
+	if entry.Before, err = models.AuditSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = models.AuditSnapshot(after); err != nil {
+		return err
+	}
+	return b.repo.Audit(ctx, entry)
+}
+
+func (b *dbBackend) Stats(ctx context.Context) (*models.CommentStats, error) {
+	return b.repo.CommentStats(ctx)
+}
+
+func (b *dbBackend) Migrate(ctx context.Context) error {
+	return b.repo.Migrate(ctx)
+}
+
+func (b *dbBackend) AuditLog(ctx context.Context, filter models.AuditFilter, limit int) ([]*models.AuditLog, error) {
+	entries, _, err := b.repo.SearchAuditLog(ctx, filter, models.NewPagination(1, int64(limit)))
+	return entries, err
+}
+
+func (b *dbBackend) VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error) {
+	return b.repo.VerifyAuditLog(ctx)
+}
@@ -0,0 +1,302 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+)
+
+// Number of comments list and search print unless -limit says otherwise
+const defaultLimit = 50
+
+// Where export writes and import reads unless -out or -in name a file
+type streams struct {
+	in  io.Reader
+	out io.Writer
+}
+
+func runCommand(ctx context.Context, b backend, p printer, s streams, args []string) error {
+	var err error
+	switch args[0] {
+	case "list":
+		err = runList(ctx, b, p, args, false)
+	case "search":
+		err = runList(ctx, b, p, args, true)
+	case "delete":
+		err = runEach(ctx, p, args, "deleted", b.Delete)
+	case "restore":
+		err = runEach(ctx, p, args, "restored", b.Restore)
+	case "export":
+		err = runExport(ctx, b, s, args)
+	case "import":
+		err = runImport(ctx, b, p, s, args)
+	case "migrate":
+		if err = b.Migrate(ctx); err == nil {
+			err = p.Message("migrated")
+		}
+	case "purge":
+		err = runPurge(ctx, b, p, args)
+	case "stats":
+		var stats *models.CommentStats
+		if stats, err = b.Stats(ctx); err == nil {
+			err = p.Stats(stats)
+		}
+	case "audit":
+		err = runAudit(ctx, b, p, args)
+	default:
+		return fmt.Errorf("unknown command %q, expected: list, search, delete, restore, export, import, migrate, purge, stats, audit", args[0])
+	}
+	if flushErr := p.Flush(); err == nil {
+		err = flushErr
+	}
+	return err
+}
+
+// Registers the flags that select comments and returns the filter they fill
+func filterFlags(flags *flag.FlagSet) func() (models.CommentFilter, error) {
+	modID := flags.String("mod", "", "only comments on this mod")
+	userID := flags.String("user", "", "only comments by this user")
+	since := flags.String("since", "", "only comments created at or after this RFC 3339 time")
+	until := flags.String("until", "", "only comments created before this RFC 3339 time")
+	pattern := flags.String("pattern", "", "only comments whose text matches this case-insensitive regular expression")
+	return func() (models.CommentFilter, error) {
+		filter := models.CommentFilter{ModID: *modID, UserID: *userID, TextPattern: *pattern}
+		var err error
+		if *since != "" {
+			if filter.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+				return filter, fmt.Errorf("invalid -since: %w", err)
+			}
+		}
+		if *until != "" {
+			if filter.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+				return filter, fmt.Errorf("invalid -until: %w", err)
+			}
+		}
+		return filter, nil
+	}
+}
+
+func runList(ctx context.Context, b backend, p printer, args []string, search bool) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	filter := filterFlags(flags)
+	limit := flags.Int("limit", defaultLimit, "maximum number of comments to print")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	selected, err := filter()
+	if err != nil {
+		return err
+	}
+	if search && selected.TextPattern == "" {
+		return fmt.Errorf("missing -pattern")
+	}
+	if *limit < 1 {
+		return fmt.Errorf("invalid -limit %d", *limit)
+	}
+
+	comments, err := b.List(ctx, selected, *limit)
+	if err != nil {
+		return err
+	}
+	return p.Comments(comments)
+}
+
+// Applies fn to every id argument, stopping at the first failure
+func runEach(ctx context.Context, p printer, args []string, done string, fn func(ctx context.Context, id string) error) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing comment id")
+	}
+	for _, id := range args[1:] {
+		if err := fn(ctx, id); err != nil {
+			return fmt.Errorf("%s: %w", id, err)
+		}
+		if err := p.Message("%s %s", done, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func runExport(ctx context.Context, b backend, s streams, args []string) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	filter := filterFlags(flags)
+	all := flags.Bool("all", false, "export every comment")
+	deleted := flags.Bool("deleted", false, "include deleted comments")
+	format := flags.String("format", archive.FormatJSONL, "format of the export: jsonl or csv")
+	output := flags.String("out", "", "file the export is written to instead of stdout")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	selected, err := filter()
+	if err != nil {
+		return err
+	}
+	if selected.ModID == "" && selected.UserID == "" && !*all {
+		return fmt.Errorf("missing -mod, -user or -all")
+	}
+	selected.IncludeDeleted = *deleted
+
+	out := s.out
+	if *output != "" {
+		file, err := os.Create(*output)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		out = file
+	}
+	writer, err := archive.NewWriter(*format, out)
+	if err != nil {
+		return err
+	}
+	err = b.Export(ctx, selected, func(comments []*models.Comment) error {
+		for _, comment := range comments {
+			if err := writer.Write(archive.RecordFromComment(comment)); err != nil {
+				return err
+			}
+		}
+		return writer.Flush()
+	})
+	if err != nil {
+		return err
+	}
+	return writer.Flush()
+}
+
+func runImport(ctx context.Context, b backend, p printer, s streams, args []string) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	format := flags.String("format", archive.FormatJSONL, "format of the import: jsonl or csv")
+	input := flags.String("in", "", "file the import is read from instead of stdin")
+	conflict := flags.String("conflict", archive.ConflictSkip, "handling of comments whose id exists: skip or overwrite")
+	batchSize := flags.Int("batch", archive.DefaultBatchSize, "comments inserted per transaction")
+	minLength := flags.Int("min-length", envInt("COMMENT_MIN_LENGTH", models.DefaultMinTextLength), "minimum text length, defaults to COMMENT_MIN_LENGTH")
+	maxLength := flags.Int("max-length", envInt("COMMENT_MAX_LENGTH", models.DefaultMaxTextLength), "maximum text length, defaults to COMMENT_MAX_LENGTH")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *minLength < 1 || *maxLength < *minLength {
+		return fmt.Errorf("invalid comment length limits: {%d-%d}", *minLength, *maxLength)
+	}
+
+	in := s.in
+	if *input != "" {
+		file, err := os.Open(*input)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		in = file
+	}
+	reader, err := archive.NewReader(*format, in)
+	if err != nil {
+		return err
+	}
+	report, err := b.Import(ctx, reader, importOptions{
+		batchSize: *batchSize,
+		conflict:  *conflict,
+		limits:    models.TextLimits{Min: *minLength, Max: *maxLength},
+	})
+	if report != nil {
+		if printErr := p.Report(report); err == nil {
+			err = printErr
+		}
+	}
+	return err
+}
+
+// Returns the integer in an environment variable, or fallback when it is unset or invalid
+func envInt(key string, fallback int) int {
+	value, err := strconv.Atoi(os.Getenv(key))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
+
+func runPurge(ctx context.Context, b backend, p printer, args []string) error {
+	flags := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	olderThan := flags.Duration("older-than", 30*24*time.Hour, "purge comments deleted longer ago than this")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	if *olderThan < 0 {
+		return fmt.Errorf("invalid -older-than %s", *olderThan)
+	}
+
+	comments, keys, err := b.Purge(ctx, time.Now().Add(-*olderThan))
+	if err != nil {
+		return err
+	}
+	return p.Message("purged %d comments and %d expired idempotency keys", comments, keys)
+}
+
+func runAudit(ctx context.Context, b backend, p printer, args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("missing audit command, expected: list, verify")
+	}
+	switch args[1] {
+	case "list":
+		return runAuditList(ctx, b, p, args[1:])
+	case "verify":
+		result, err := b.VerifyAuditLog(ctx)
+		if err != nil {
+			return err
+		}
+		if err := p.Verification(result); err != nil {
+			return err
+		}
+		if result.BrokenAt != 0 {
+			return fmt.Errorf("audit log is broken at entry %d: %s", result.BrokenAt, result.Reason)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unknown audit command %q, expected: list, verify", args[1])
+	}
+}
+
+func runAuditList(ctx context.Context, b backend, p printer, args []string) error {
+	flags := flag.NewFlagSet("audit "+args[0], flag.ContinueOnError)
+	flags.SetOutput(io.Discard)
+	actor := flags.String("actor", "", "only entries of this user")
+	action := flags.String("action", "", "only entries of this action, like comment.delete")
+	target := flags.String("target", "", "only entries about this comment, user or mod")
+	requestID := flags.String("request", "", "only entries of this request id")
+	since := flags.String("since", "", "only entries created at or after this RFC 3339 time")
+	until := flags.String("until", "", "only entries created before this RFC 3339 time")
+	limit := flags.Int("limit", defaultLimit, "maximum number of entries to print")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+	filter := models.AuditFilter{Actor: *actor, Action: *action, Target: *target, RequestID: *requestID}
+	var err error
+	if *since != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, *since); err != nil {
+			return fmt.Errorf("invalid -since: %w", err)
+		}
+	}
+	if *until != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, *until); err != nil {
+			return fmt.Errorf("invalid -until: %w", err)
+		}
+	}
+	if *limit < 1 || *limit > models.MaxPageSize {
+		return fmt.Errorf("invalid -limit %d, must be between 1 and %d", *limit, models.MaxPageSize)
+	}
+
+	entries, err := b.AuditLog(ctx, filter, *limit)
+	if err != nil {
+		return err
+	}
+	return p.AuditLog(entries)
+}
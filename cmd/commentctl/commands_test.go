@@ -0,0 +1,411 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+)
+
+type fakeBackend struct {
+	comments []*models.Comment
+	filter   models.CommentFilter
+	imported []archive.Record
+	options  importOptions
+	deleted  []string
+	before   time.Time
+	audit    []*models.AuditLog
+	auditBy  models.AuditFilter
+	verified *models.AuditVerification
+}
+
+func (b *fakeBackend) List(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error) {
+	b.filter = filter
+	return b.comments, nil
+}
+
+func (b *fakeBackend) Export(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error {
+	b.filter = filter
+	for _, comment := range b.comments {
+		if err := fn([]*models.Comment{comment}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (b *fakeBackend) Import(ctx context.Context, r archive.Reader, options importOptions) (*archive.Report, error) {
+	b.options = options
+	report := &archive.Report{}
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			return report, nil
+		}
+		if err != nil {
+			return report, err
+		}
+		report.Read++
+		report.Created++
+		b.imported = append(b.imported, record)
+	}
+}
+
+func (b *fakeBackend) Delete(ctx context.Context, id string) error {
+	b.deleted = append(b.deleted, id)
+	return nil
+}
+
+func (b *fakeBackend) Restore(ctx context.Context, id string) error {
+	return errUnsupported
+}
+
+func (b *fakeBackend) Purge(ctx context.Context, before time.Time) (int64, int64, error) {
+	b.before = before
+	return 3, 1, nil
+}
+
+func (b *fakeBackend) Stats(ctx context.Context) (*models.CommentStats, error) {
+	return &models.CommentStats{Total: 7, Deleted: 2}, nil
+}
+
+func (b *fakeBackend) Migrate(ctx context.Context) error {
+	return nil
+}
+
+func (b *fakeBackend) AuditLog(ctx context.Context, filter models.AuditFilter, limit int) ([]*models.AuditLog, error) {
+	b.auditBy = filter
+	return b.audit, nil
+}
+
+func (b *fakeBackend) VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error) {
+	return b.verified, nil
+}
+
+func runFake(b backend, format string, args ...string) (string, error) {
+	return runFakeInput(b, format, "", args...)
+}
+
+func runFakeInput(b backend, format string, input string, args ...string) (string, error) {
+	var out bytes.Buffer
+	p, err := newPrinter(format, &out)
+	if err != nil {
+		return "", err
+	}
+	err = runCommand(context.Background(), b, p, streams{in: strings.NewReader(input), out: &out}, args)
+	return out.String(), err
+}
+
+// will test listing prints a table row per comment with the text on one line
+func TestListTable(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	b := &fakeBackend{comments: []*models.Comment{{ID: uuid.NewString(), ModID: modID, UserID: "user", Status: models.StatusPublished, Text: "first line\nsecond line"}}}
+
+	// Act
+	out, err := runFake(b, "table", "list", "-mod", modID, "-since", "2023-01-01T00:00:00Z")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, modID, b.filter.ModID)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), b.filter.Since)
+	assert.Contains(t, out, "ID")
+	assert.Contains(t, out, "first line second line")
+}
+
+// will test exporting writes one record per line including deleted comments when asked
+func TestExportJSONL(t *testing.T) {
+	// Arrange
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	b := &fakeBackend{comments: []*models.Comment{{ID: "a", UserID: "user", CreatedAt: created}, {ID: "b", UserID: "user", CreatedAt: created}}}
+
+	// Act
+	out, err := runFake(b, "table", "export", "-user", "user", "-deleted")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, b.filter.IncludeDeleted)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 2)
+	var record archive.Record
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.Equal(t, "b", record.ID)
+	assert.Equal(t, created, record.UpdatedAt)
+}
+
+// will test exporting every comment as csv
+func TestExportAllCSV(t *testing.T) {
+	// Arrange
+	b := &fakeBackend{comments: []*models.Comment{{ID: "a", UserID: "user", Text: "nice, track"}}}
+
+	// Act
+	out, err := runFake(b, "table", "export", "-all", "-format", "csv")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, models.CommentFilter{}, b.filter)
+	lines := strings.Split(strings.TrimSpace(out), "\n")
+	assert.Len(t, lines, 2)
+	assert.Equal(t, "id,modId,userId,text,status,hidden,createdAt,updatedAt,deletedAt", lines[0])
+	assert.Contains(t, lines[1], `"nice, track"`)
+}
+
+// will test exporting without selecting comments
+func TestExportMissingFilter(t *testing.T) {
+	// Act
+	_, err := runFake(&fakeBackend{}, "table", "export")
+
+	// Assert
+	assert.EqualError(t, err, "missing -mod, -user or -all")
+}
+
+// will test importing reads the records from the input and prints the report
+func TestImport(t *testing.T) {
+	// Arrange
+	b := &fakeBackend{}
+	input := `{"id":"a","modId":"mod","userId":"user","text":"nice track","createdAt":"2021-03-01T12:00:00Z"}` + "\n"
+
+	// Act
+	out, err := runFakeInput(b, "table", input, "import", "-conflict", "overwrite", "-max-length", "500")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, b.imported, 1)
+	assert.Equal(t, archive.ConflictOverwrite, b.options.conflict)
+	assert.Equal(t, 500, b.options.limits.Max)
+	assert.Contains(t, out, "created:   1")
+}
+
+// will test searching without a pattern
+func TestSearchMissingPattern(t *testing.T) {
+	// Act
+	_, err := runFake(&fakeBackend{}, "table", "search", "-mod", uuid.NewString())
+
+	// Assert
+	assert.EqualError(t, err, "missing -pattern")
+}
+
+// will test deleting every id argument
+func TestDelete(t *testing.T) {
+	// Arrange
+	b := &fakeBackend{}
+
+	// Act
+	out, err := runFake(b, "table", "delete", "a", "b")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"a", "b"}, b.deleted)
+	assert.Equal(t, "deleted a\ndeleted b\n", out)
+}
+
+// will test a command the backend does not support
+func TestRestoreUnsupported(t *testing.T) {
+	// Act
+	_, err := runFake(&fakeBackend{}, "table", "restore", "a")
+
+	// Assert
+	assert.ErrorIs(t, err, errUnsupported)
+}
+
+// will test purging with a retention cutoff
+func TestPurge(t *testing.T) {
+	// Arrange
+	b := &fakeBackend{}
+
+	// Act
+	out, err := runFake(b, "table", "purge", "-older-than", "48h")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), b.before, time.Minute)
+	assert.Equal(t, "purged 3 comments and 1 expired idempotency keys\n", out)
+}
+
+// Repository recording the calls of the database backend, audits fail when auditErr is set
+type recordingRepository struct {
+	repository.ModRepository
+	calls    []string
+	audited  []*models.AuditLog
+	auditErr error
+}
+
+func (r *recordingRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	r.calls = append(r.calls, "begin")
+	if err := fn(ctx); err != nil {
+		r.calls = append(r.calls, "rollback")
+		return err
+	}
+	r.calls = append(r.calls, "commit")
+	return nil
+}
+
+func (r *recordingRepository) FindByID(ctx context.Context, id string) (*models.Comment, error) {
+	return &models.Comment{ID: id, Text: "nice track"}, nil
+}
+
+func (r *recordingRepository) Delete(ctx context.Context, id string) error {
+	r.calls = append(r.calls, "delete "+id)
+	return nil
+}
+
+func (r *recordingRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	r.calls = append(r.calls, "purge")
+	return 3, errors.New("canceling statement due to statement timeout")
+}
+
+func (r *recordingRepository) Audit(ctx context.Context, entries ...*models.AuditLog) error {
+	r.calls = append(r.calls, "audit")
+	r.audited = append(r.audited, entries...)
+	return r.auditErr
+}
+
+// will test the database backend audits a delete in its transaction as the actor
+func TestDBBackendDeleteAudited(t *testing.T) {
+	// Arrange
+	repo := &recordingRepository{}
+	b := &dbBackend{repo: repo, actor: "ops-1"}
+
+	// Act
+	err := b.Delete(context.Background(), "a")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"begin", "delete a", "audit", "commit"}, repo.calls)
+	assert.Equal(t, "ops-1", repo.audited[0].Actor)
+	assert.Equal(t, models.AuditActionDelete, repo.audited[0].Action)
+	assert.Contains(t, repo.audited[0].Before, `"id":"a"`)
+	assert.NotContains(t, repo.audited[0].Before, "nice track")
+}
+
+// will test the database backend rolls a delete back when it cannot be audited
+func TestDBBackendDeleteAuditFailed(t *testing.T) {
+	// Arrange
+	repo := &recordingRepository{auditErr: errors.New("connection reset")}
+	b := &dbBackend{repo: repo, actor: "ops-1"}
+
+	// Act
+	err := b.Delete(context.Background(), "a")
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, []string{"begin", "delete a", "audit", "rollback"}, repo.calls)
+}
+
+// will test a purge that stops part way is audited with the comments it purged, outside of any transaction
+func TestDBBackendPurgeStopped(t *testing.T) {
+	// Arrange
+	repo := &recordingRepository{}
+	b := &dbBackend{repo: repo, actor: "ops-1"}
+
+	// Act
+	comments, _, err := b.Purge(context.Background(), time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC))
+
+	// Assert
+	assert.Error(t, err)
+	assert.Equal(t, int64(3), comments)
+	assert.Equal(t, []string{"purge", "audit"}, repo.calls)
+	assert.Equal(t, `before=2023-01-01T00:00:00Z purged=3 error="canceling statement due to statement timeout"`, repo.audited[0].Detail)
+}
+
+// Comment service answering listings by mod, keeping the last request
+type fakeCommentClient struct {
+	protobuffer.CommentServiceClient
+	byMod *protobuffer.GetCommentByModIDRequest
+}
+
+func (c *fakeCommentClient) GetCommentByModID(ctx context.Context, in *protobuffer.GetCommentByModIDRequest, opts ...grpc.CallOption) (*protobuffer.GetCommentByModIDResponse, error) {
+	c.byMod = in
+	return &protobuffer.GetCommentByModIDResponse{}, nil
+}
+
+// will test the gRPC backend refuses to export deleted comments it cannot list
+func TestGrpcBackendExportDeletedUnsupported(t *testing.T) {
+	// Arrange
+	client := &fakeCommentClient{}
+	b := &grpcBackend{client: client, timeout: time.Second}
+
+	// Act
+	err := b.Export(context.Background(), models.CommentFilter{ModID: uuid.NewString(), IncludeDeleted: true}, func(comments []*models.Comment) error { return nil })
+
+	// Assert
+	assert.ErrorIs(t, err, errUnsupported)
+	assert.Nil(t, client.byMod)
+}
+
+// will test the gRPC backend leaves hidden comments out of listings by mod, as of listings by user
+func TestGrpcBackendExportByModHidden(t *testing.T) {
+	// Arrange
+	client := &fakeCommentClient{}
+	b := &grpcBackend{client: client, timeout: time.Second}
+
+	// Act
+	err := b.Export(context.Background(), models.CommentFilter{ModID: uuid.NewString()}, func(comments []*models.Comment) error { return nil })
+
+	// Assert
+	assert.NoError(t, err)
+	assert.False(t, client.byMod.IncludeHidden)
+}
+
+// will test the gRPC backend applies the filter the API cannot
+func TestFilterMatcher(t *testing.T) {
+	// Arrange
+	since := time.Now().Add(-time.Hour)
+
+	// Act
+	match, err := filterMatcher(models.CommentFilter{UserID: "user", Since: since, TextPattern: "free .*skins"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.True(t, match(&models.Comment{UserID: "user", CreatedAt: time.Now(), Text: "FREE bike skins"}))
+	assert.False(t, match(&models.Comment{UserID: "other", CreatedAt: time.Now(), Text: "free skins"}))
+	assert.False(t, match(&models.Comment{UserID: "user", CreatedAt: since.Add(-time.Minute), Text: "free skins"}))
+	assert.False(t, match(&models.Comment{UserID: "user", CreatedAt: time.Now(), Text: "nice track"}))
+}
+
+// will test audit list passes its filters and prints an entry per line
+func TestAuditListJSON(t *testing.T) {
+	// Arrange
+	target := uuid.NewString()
+	b := &fakeBackend{audit: []*models.AuditLog{{Sequence: 9, Actor: "moderator-1", Action: models.AuditActionDelete, Target: target, RequestID: "req-1", Hash: "ab"}}}
+
+	// Act
+	out, err := runFake(b, "json", "audit", "list", "-actor", "moderator-1", "-action", models.AuditActionDelete, "-since", "2023-01-01T00:00:00Z")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, "moderator-1", b.auditBy.Actor)
+	assert.Equal(t, models.AuditActionDelete, b.auditBy.Action)
+	assert.Equal(t, time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC), b.auditBy.Since)
+	var row auditRow
+	assert.NoError(t, json.Unmarshal([]byte(out), &row))
+	assert.Equal(t, int64(9), row.Sequence)
+	assert.Equal(t, target, row.Target)
+	assert.Equal(t, "req-1", row.RequestID)
+}
+
+// will test audit verify prints the result and fails on a broken chain
+func TestAuditVerifyBroken(t *testing.T) {
+	// Arrange
+	b := &fakeBackend{verified: &models.AuditVerification{Entries: 4, LastHash: "ab", BrokenAt: 5, Reason: "hash does not match the entry"}}
+
+	// Act
+	out, err := runFake(b, "table", "audit", "verify")
+
+	// Assert
+	assert.EqualError(t, err, "audit log is broken at entry 5: hash does not match the entry")
+	assert.Contains(t, out, "broken at:")
+	assert.Contains(t, out, "hash does not match the entry")
+}
@@ -0,0 +1,252 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Ends an export early once a listing has enough comments
+var errLimitReached = errors.New("limit reached")
+
+// Runs commands through the comment service, so they pass its authorization, cache and audit log
+type grpcBackend struct {
+	client  protobuffer.CommentServiceClient
+	userID  string
+	roles   string
+	timeout time.Duration
+}
+
+func newGrpcBackend(conn grpc.ClientConnInterface, userID string, roles string, timeout time.Duration) *grpcBackend {
+	return &grpcBackend{client: protobuffer.NewCommentServiceClient(conn), userID: userID, roles: roles, timeout: timeout}
+}
+
+// Returns the context of a single call, carrying the caller metadata the service authorizes with
+func (b *grpcBackend) call(ctx context.Context) (context.Context, context.CancelFunc) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", b.userID, "x-user-roles", b.roles)
+	return context.WithTimeout(ctx, b.timeout)
+}
+
+func (b *grpcBackend) List(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error) {
+	var result []*models.Comment
+	err := b.Export(ctx, filter, func(comments []*models.Comment) error {
+		result = append(result, comments...)
+		if len(result) >= limit {
+			return errLimitReached
+		}
+		return nil
+	})
+	if err == errLimitReached {
+		err = nil
+	}
+	if len(result) > limit {
+		result = result[:limit]
+	}
+	return result, err
+}
+
+// The API only lists by mod or by user, the rest of the filter is applied here. It never lists
+// deleted comments and, as listings by user cannot include them, leaves hidden comments out of both.
+func (b *grpcBackend) Export(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error {
+	if filter.IncludeDeleted {
+		return errUnsupported
+	}
+	match, err := filterMatcher(filter)
+	if err != nil {
+		return err
+	}
+	emit := func(comments []*protobuffer.Comment) error {
+		var batch []*models.Comment
+		for _, comment := range comments {
+			if converted := models.CommentFromProto(comment); match(converted) {
+				batch = append(batch, converted)
+			}
+		}
+		if len(batch) == 0 {
+			return nil
+		}
+		return fn(batch)
+	}
+
+	if filter.ModID != "" {
+		request := &protobuffer.GetCommentByModIDRequest{ModID: filter.ModID, UserID: filter.UserID}
+		if !filter.Since.IsZero() {
+			request.Since = timestamppb.New(filter.Since)
+		}
+		if !filter.Until.IsZero() {
+			request.Until = timestamppb.New(filter.Until)
+		}
+		callCtx, cancel := b.call(ctx)
+		defer cancel()
+		response, err := b.client.GetCommentByModID(callCtx, request)
+		if err != nil {
+			return err
+		}
+		return emit(response.Comments)
+	}
+	if filter.UserID == "" {
+		return fmt.Errorf("the gRPC API only lists by -mod or -user")
+	}
+	for page := int64(1); ; page++ {
+		callCtx, cancel := b.call(ctx)
+		response, err := b.client.GetCommentsByUserID(callCtx, &protobuffer.GetCommentsByUserIDRequest{UserID: filter.UserID, Page: page, Size: models.MaxPageSize})
+		cancel()
+		if err != nil {
+			return err
+		}
+		if err := emit(response.Comments); err != nil {
+			return err
+		}
+		if !response.Pagination.GetHasMore() {
+			return nil
+		}
+	}
+}
+
+// Streams the records to the service, which validates them by its own text limits and batch size. Records
+// that cannot be decoded are rejected here.
+func (b *grpcBackend) Import(ctx context.Context, r archive.Reader, options importOptions) (*archive.Report, error) {
+	ctx = metadata.AppendToOutgoingContext(ctx, "x-user-id", b.userID, "x-user-roles", b.roles)
+	stream, err := b.client.ImportComments(ctx)
+	if err != nil {
+		return nil, err
+	}
+	conflict := protobuffer.ImportConflict_SKIP
+	if options.conflict == archive.ConflictOverwrite {
+		conflict = protobuffer.ImportConflict_OVERWRITE
+	}
+
+	report := &archive.Report{Rejected: []archive.Rejection{}}
+	var lines []int
+	for {
+		record, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		var recordErr *archive.RecordError
+		if errors.As(err, &recordErr) {
+			report.Read++
+			report.Rejected = append(report.Rejected, archive.Rejection{Line: recordErr.Line, Reason: recordErr.Err.Error()})
+			continue
+		}
+		if err != nil {
+			stream.CloseSend()
+			return nil, err
+		}
+		if err := stream.Send(&protobuffer.ImportCommentsRequest{Comment: archive.RecordToProto(record), Conflict: conflict}); err != nil {
+			// The service ended the stream, its status comes with the response
+			break
+		}
+		lines = append(lines, r.Line())
+	}
+	response, err := stream.CloseAndRecv()
+	if err != nil {
+		return nil, err
+	}
+
+	report.Read += int(response.Received)
+	report.Created = int(response.Created)
+	report.Updated = int(response.Updated)
+	report.Skipped = int(response.Skipped)
+	for _, importErr := range response.Errors {
+		rejection := archive.Rejection{ID: importErr.ID, Reason: importErr.Error}
+		if index := int(importErr.Index); index > 0 && index <= len(lines) {
+			rejection.Line = lines[index-1]
+		}
+		report.Rejected = append(report.Rejected, rejection)
+	}
+	if omitted := int(response.Rejected) - len(response.Errors); omitted > 0 {
+		report.Rejected = append(report.Rejected, archive.Rejection{Reason: fmt.Sprintf("%d more rejected comments not listed by the service", omitted)})
+	}
+	return report, nil
+}
+
+func (b *grpcBackend) Delete(ctx context.Context, id string) error {
+	ctx, cancel := b.call(ctx)
+	defer cancel()
+	_, err := b.client.DeleteComment(ctx, &protobuffer.DeleteCommentRequest{ID: id})
+	return err
+}
+
+func (b *grpcBackend) Restore(ctx context.Context, id string) error {
+	return errUnsupported
+}
+
+func (b *grpcBackend) Purge(ctx context.Context, before time.Time) (int64, int64, error) {
+	return 0, 0, errUnsupported
+}
+
+func (b *grpcBackend) Stats(ctx context.Context) (*models.CommentStats, error) {
+	return nil, errUnsupported
+}
+
+func (b *grpcBackend) Migrate(ctx context.Context) error {
+	return errUnsupported
+}
+
+func (b *grpcBackend) AuditLog(ctx context.Context, filter models.AuditFilter, limit int) ([]*models.AuditLog, error) {
+	ctx, cancel := b.call(ctx)
+	defer cancel()
+	req := &protobuffer.QueryAuditLogRequest{
+		Actor:     filter.Actor,
+		Action:    filter.Action,
+		Target:    filter.Target,
+		RequestID: filter.RequestID,
+		Page:      1,
+		Size:      int64(limit),
+	}
+	if !filter.Since.IsZero() {
+		req.Since = timestamppb.New(filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		req.Until = timestamppb.New(filter.Until)
+	}
+	res, err := b.client.QueryAuditLog(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	entries := make([]*models.AuditLog, 0, len(res.Entries))
+	for _, entry := range res.Entries {
+		entries = append(entries, models.AuditLogFromProto(entry))
+	}
+	return entries, nil
+}
+
+// Verifying reads the whole log, which only the database can do
+func (b *grpcBackend) VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error) {
+	return nil, errUnsupported
+}
+
+// Returns whether a comment passes the parts of a filter the API cannot apply
+func filterMatcher(filter models.CommentFilter) (func(comment *models.Comment) bool, error) {
+	var pattern *regexp.Regexp
+	if filter.TextPattern != "" {
+		var err error
+		if pattern, err = regexp.Compile("(?i)" + filter.TextPattern); err != nil {
+			return nil, err
+		}
+	}
+	return func(comment *models.Comment) bool {
+		switch {
+		case filter.UserID != "" && comment.UserID != filter.UserID:
+			return false
+		case !filter.Since.IsZero() && comment.CreatedAt.Before(filter.Since):
+			return false
+		case !filter.Until.IsZero() && !comment.CreatedAt.Before(filter.Until):
+			return false
+		case pattern != nil && !pattern.MatchString(comment.Text):
+			return false
+		}
+		return true
+	}, nil
+}
@@ -0,0 +1,97 @@
+// Command commentctl runs operations tasks against the comment database or the gRPC API
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+const usage = `usage: commentctl [-db DSN | -addr HOST:PORT] [-o table|json] COMMAND [ARGS]
+
+commands:
+  list    [-mod ID] [-user ID] [-since T] [-until T] [-limit N]   list comments, oldest first
+  search  -pattern RE [-mod ID] [-user ID] [-limit N]             list comments whose text matches RE
+  delete  ID...                                                   delete comments
+  restore ID...                                                   undo the deletion of comments (-db only)
+  export  -mod ID | -user ID | -all [-deleted] [-format F] [-out FILE]
+                                                                  stream comments as jsonl or csv (-deleted needs -db)
+  import  [-format F] [-in FILE] [-conflict skip|overwrite] [-batch N]
+                                                                  validate and insert exported comments
+  migrate                                                         migrate the database schema (-db only)
+  purge   [-older-than D]                                         hard delete comments deleted more than D ago (-db only)
+  stats                                                           report comment counts (-db only)
+  audit list [-actor U] [-action A] [-target ID] [-request ID] [-since T] [-until T] [-limit N]
+                                                                  list audit entries, newest first
+  audit verify                                                    check the hash chain of the audit log (-db only)
+`
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if err := run(ctx, os.Args[1:], os.Stdin, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "commentctl:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, in io.Reader, out io.Writer, errOut io.Writer) error {
+	flags := flag.NewFlagSet("commentctl", flag.ContinueOnError)
+	flags.SetOutput(errOut)
+	flags.Usage = func() { fmt.Fprint(errOut, usage) }
+	dsn := flags.String("db", os.Getenv("POSTGRES_URI"), "postgres DSN, defaults to POSTGRES_URI")
+	addr := flags.String("addr", "", "address of the comment service, used instead of the database when set")
+	caller := flags.String("as", "commentctl", "user id the calls are made and audited as")
+	roles := flags.String("roles", "admin", "comma separated roles the gRPC calls are made with")
+	format := flags.String("o", "table", "output format: table or json")
+	timeout := flags.Duration("timeout", 30*time.Second, "timeout of a single query or call, streams are not limited by it")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+	if flags.NArg() == 0 {
+		flags.Usage()
+		return fmt.Errorf("missing command")
+	}
+	printer, err := newPrinter(*format, out)
+	if err != nil {
+		return err
+	}
+
+	var b backend
+	switch {
+	case *addr != "":
+		conn, err := grpc.Dial(*addr, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			return err
+		}
+		defer conn.Close()
+		b = newGrpcBackend(conn, *caller, *roles, *timeout)
+	case *dsn != "":
+		db, err := gorm.Open(postgres.Open(*dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+		if err != nil {
+			return err
+		}
+		b = &dbBackend{repo: repository.NewRepository(db, repository.WithQueryTimeout(*timeout)), actor: *caller}
+	default:
+		return fmt.Errorf("missing -db or -addr")
+	}
+	err = runCommand(ctx, b, printer, streams{in: in, out: out}, flags.Args())
+	if errors.Is(err, flag.ErrHelp) {
+		flags.Usage()
+		return nil
+	}
+	return err
+}
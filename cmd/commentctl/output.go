@@ -0,0 +1,227 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+)
+
+// Longest text a table row shows
+const tableTextLength = 60
+
+// A comment as commentctl prints it
+type commentRow struct {
+	ID        string     `json:"id"`
+	ModID     string     `json:"modId"`
+	UserID    string     `json:"userId"`
+	Status    string     `json:"status"`
+	Hidden    bool       `json:"hidden"`
+	CreatedAt time.Time  `json:"createdAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+	Text      string     `json:"text"`
+}
+
+func newCommentRow(comment *models.Comment) commentRow {
+	row := commentRow{
+		ID:        comment.ID,
+		ModID:     comment.ModID,
+		UserID:    comment.UserID,
+		Status:    comment.Status,
+		Hidden:    comment.Hidden,
+		CreatedAt: comment.CreatedAt,
+		Text:      comment.Text,
+	}
+	if comment.DeletedAt.Valid {
+		row.DeletedAt = &comment.DeletedAt.Time
+	}
+	return row
+}
+
+// An audit entry as commentctl prints it
+type auditRow struct {
+	Sequence  int64     `json:"sequence"`
+	CreatedAt time.Time `json:"createdAt"`
+	Actor     string    `json:"actor"`
+	Action    string    `json:"action"`
+	Target    string    `json:"target,omitempty"`
+	Detail    string    `json:"detail,omitempty"`
+	Before    string    `json:"before,omitempty"`
+	After     string    `json:"after,omitempty"`
+	RequestID string    `json:"requestId,omitempty"`
+	ClientIP  string    `json:"clientIp,omitempty"`
+	PrevHash  string    `json:"prevHash"`
+	Hash      string    `json:"hash"`
+}
+
+func newAuditRow(entry *models.AuditLog) auditRow {
+	return auditRow{
+		Sequence:  entry.Sequence,
+		CreatedAt: entry.CreatedAt,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Detail:    entry.Detail,
+		Before:    entry.Before,
+		After:     entry.After,
+		RequestID: entry.RequestID,
+		ClientIP:  entry.ClientIP,
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	}
+}
+
+// Writes command results as an aligned table or as one JSON object per line
+type printer interface {
+	Comments(comments []*models.Comment) error
+	Stats(stats *models.CommentStats) error
+	Report(report *archive.Report) error
+	AuditLog(entries []*models.AuditLog) error
+	Verification(result *models.AuditVerification) error
+	Message(format string, args ...interface{}) error
+	Flush() error
+}
+
+func newPrinter(format string, out io.Writer) (printer, error) {
+	switch format {
+	case "table":
+		return &tablePrinter{w: tabwriter.NewWriter(out, 0, 4, 2, ' ', 0)}, nil
+	case "json":
+		return &jsonPrinter{enc: json.NewEncoder(out)}, nil
+	default:
+		return nil, fmt.Errorf("unknown output format %q, expected: table, json", format)
+	}
+}
+
+type tablePrinter struct {
+	w      *tabwriter.Writer
+	header bool
+}
+
+func (p *tablePrinter) Comments(comments []*models.Comment) error {
+	if !p.header {
+		p.header = true
+		fmt.Fprintln(p.w, "ID\tMOD\tUSER\tSTATUS\tHIDDEN\tCREATED\tTEXT")
+	}
+	for _, comment := range comments {
+		row := newCommentRow(comment)
+		status := row.Status
+		if row.DeletedAt != nil {
+			status = "deleted"
+		}
+		fmt.Fprintf(p.w, "%s\t%s\t%s\t%s\t%t\t%s\t%s\n", row.ID, row.ModID, row.UserID, status, row.Hidden, row.CreatedAt.UTC().Format(time.RFC3339), shorten(row.Text))
+	}
+	return nil
+}
+
+func (p *tablePrinter) Stats(stats *models.CommentStats) error {
+	fmt.Fprintf(p.w, "total:\t%d\n", stats.Total)
+	fmt.Fprintf(p.w, "published:\t%d\n", stats.Published)
+	fmt.Fprintf(p.w, "pending:\t%d\n", stats.Pending)
+	fmt.Fprintf(p.w, "rejected:\t%d\n", stats.Rejected)
+	fmt.Fprintf(p.w, "hidden:\t%d\n", stats.Hidden)
+	fmt.Fprintf(p.w, "deleted:\t%d\n", stats.Deleted)
+	fmt.Fprintf(p.w, "mods:\t%d\n", stats.Mods)
+	fmt.Fprintf(p.w, "authors:\t%d\n", stats.Authors)
+	fmt.Fprintf(p.w, "last 24h:\t%d\n", stats.LastDay)
+	return nil
+}
+
+func (p *tablePrinter) Report(report *archive.Report) error {
+	fmt.Fprintf(p.w, "read:\t%d\n", report.Read)
+	fmt.Fprintf(p.w, "created:\t%d\n", report.Created)
+	fmt.Fprintf(p.w, "updated:\t%d\n", report.Updated)
+	fmt.Fprintf(p.w, "skipped:\t%d\n", report.Skipped)
+	fmt.Fprintf(p.w, "rejected:\t%d\n", len(report.Rejected))
+	if len(report.Rejected) == 0 {
+		return nil
+	}
+	fmt.Fprintln(p.w, "\nLINE\tID\tREASON")
+	for _, rejection := range report.Rejected {
+		fmt.Fprintf(p.w, "%d\t%s\t%s\n", rejection.Line, rejection.ID, rejection.Reason)
+	}
+	return nil
+}
+
+func (p *tablePrinter) AuditLog(entries []*models.AuditLog) error {
+	fmt.Fprintln(p.w, "SEQ\tCREATED\tACTOR\tACTION\tTARGET\tREQUEST\tCLIENT\tDETAIL")
+	for _, entry := range entries {
+		fmt.Fprintf(p.w, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n", entry.Sequence, entry.CreatedAt.UTC().Format(time.RFC3339), entry.Actor, entry.Action, entry.Target, entry.RequestID, entry.ClientIP, shorten(entry.Detail))
+	}
+	return nil
+}
+
+func (p *tablePrinter) Verification(result *models.AuditVerification) error {
+	fmt.Fprintf(p.w, "entries:\t%d\n", result.Entries)
+	fmt.Fprintf(p.w, "last hash:\t%s\n", result.LastHash)
+	if result.BrokenAt != 0 {
+		fmt.Fprintf(p.w, "broken at:\t%d\n", result.BrokenAt)
+		fmt.Fprintf(p.w, "reason:\t%s\n", result.Reason)
+	}
+	return nil
+}
+
+func (p *tablePrinter) Message(format string, args ...interface{}) error {
+	_, err := fmt.Fprintf(p.w, format+"\n", args...)
+	return err
+}
+
+func (p *tablePrinter) Flush() error {
+	return p.w.Flush()
+}
+
+type jsonPrinter struct {
+	enc *json.Encoder
+}
+
+func (p *jsonPrinter) Comments(comments []*models.Comment) error {
+	for _, comment := range comments {
+		if err := p.enc.Encode(newCommentRow(comment)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *jsonPrinter) Stats(stats *models.CommentStats) error {
+	return p.enc.Encode(stats)
+}
+
+func (p *jsonPrinter) Report(report *archive.Report) error {
+	return p.enc.Encode(report)
+}
+
+func (p *jsonPrinter) AuditLog(entries []*models.AuditLog) error {
+	for _, entry := range entries {
+		if err := p.enc.Encode(newAuditRow(entry)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *jsonPrinter) Verification(result *models.AuditVerification) error {
+	return p.enc.Encode(result)
+}
+
+func (p *jsonPrinter) Message(format string, args ...interface{}) error {
+	return p.enc.Encode(map[string]string{"message": fmt.Sprintf(format, args...)})
+}
+
+func (p *jsonPrinter) Flush() error {
+	return nil
+}
+
+// Returns the text on a single line, cut to the table width
+func shorten(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	if runes := []rune(text); len(runes) > tableTextLength {
+		return string(runes[:tableTextLength-1]) + "…"
+	}
+	return text
+}
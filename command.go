@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
+)
+
+// Source of the moderator decisions the spam model learns from
+type trainingSource interface {
+	SearchTrainingSet(ctx context.Context) ([]*models.LabeledText, error)
+}
+
+// Run a maintenance command instead of the server
+func runCommand(ctx context.Context, source trainingSource, args []string, out io.Writer) error {
+	switch args[0] {
+	case "spam":
+		return runSpamCommand(ctx, source, args[1:], out)
+	default:
+		return fmt.Errorf("unknown command %q, expected: spam", args[0])
+	}
+}
+
+// spam train  -out FILE                   train the token model from every review and write it to FILE
+// spam evaluate -holdout N -threshold T   train on all but every N-th review and report on the held out ones
+func runSpamCommand(ctx context.Context, source trainingSource, args []string, out io.Writer) error {
+	if len(args) == 0 {
+		return fmt.Errorf("missing spam command, expected: train, evaluate")
+	}
+
+	flags := flag.NewFlagSet("spam "+args[0], flag.ContinueOnError)
+	flags.SetOutput(out)
+	output := flags.String("out", spamModelFile, "file the trained model is written to")
+	holdout := flags.Int("holdout", 5, "hold out every n-th sample for evaluation")
+	threshold := flags.Float64("threshold", spam.DefaultThresholds.Review, "probability at which a sample counts as spam")
+	if err := flags.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	texts, err := source.SearchTrainingSet(ctx)
+	if err != nil {
+		return err
+	}
+	samples := make([]spam.Sample, 0, len(texts))
+	for _, text := range texts {
+		samples = append(samples, spam.Sample{Text: text.Text, Spam: text.Spam})
+	}
+
+	switch args[0] {
+	case "train":
+		if *output == "" {
+			return fmt.Errorf("missing -out or SPAM_MODEL_FILE")
+		}
+		model := spam.Train(samples)
+		if err := model.Save(*output); err != nil {
+			return err
+		}
+		fmt.Fprintf(out, "trained on %d spam and %d ham samples, written to %s\n", model.SpamDocuments, model.HamDocuments, *output)
+	case "evaluate":
+		report := spam.Evaluate(samples, *holdout, *threshold)
+		fmt.Fprintf(out, "samples:   %d\n", report.Total())
+		fmt.Fprintf(out, "tp/fp:     %d/%d\n", report.TruePositives, report.FalsePositives)
+		fmt.Fprintf(out, "tn/fn:     %d/%d\n", report.TrueNegatives, report.FalseNegatives)
+		fmt.Fprintf(out, "precision: %.3f\n", report.Precision())
+		fmt.Fprintf(out, "recall:    %.3f\n", report.Recall())
+		fmt.Fprintf(out, "accuracy:  %.3f\n", report.Accuracy())
+	default:
+		return fmt.Errorf("unknown spam command %q, expected: train, evaluate", args[0])
+	}
+	return nil
+}
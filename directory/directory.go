@@ -0,0 +1,51 @@
+package directory
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+	"strings"
+)
+
+// UserDirectory resolves user names to user ids
+type UserDirectory interface {
+	// Return the user id of every known name, keyed by the lower case name
+	ResolveNames(ctx context.Context, names []string) (map[string]string, error)
+}
+
+type fileDirectory struct {
+	users map[string]string
+}
+
+// Return a directory backed by a JSON file mapping user names to user ids
+func NewFileDirectory(path string) (*fileDirectory, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var users map[string]string
+	if err := json.Unmarshal(data, &users); err != nil {
+		return nil, err
+	}
+	return NewStaticDirectory(users), nil
+}
+
+// Return a directory backed by an in-memory map of user names to user ids
+func NewStaticDirectory(users map[string]string) *fileDirectory {
+	d := &fileDirectory{users: make(map[string]string, len(users))}
+	for name, id := range users {
+		d.users[strings.ToLower(name)] = id
+	}
+	return d
+}
+
+func (d *fileDirectory) ResolveNames(ctx context.Context, names []string) (map[string]string, error) {
+	result := make(map[string]string, len(names))
+	for _, name := range names {
+		if id, ok := d.users[strings.ToLower(name)]; ok {
+			result[strings.ToLower(name)] = id
+		}
+	}
+	return result, nil
+}
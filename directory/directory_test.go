@@ -0,0 +1,22 @@
+package directory
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// will test resolving names from a file
+func TestFileDirectoryResolveNames(t *testing.T) {
+	// Arrange
+	directory, err := NewFileDirectory("testdata/users.json")
+	assert.NoError(t, err)
+
+	// Act
+	resolved, err := directory.ResolveNames(context.Background(), []string{"rider42", "MX_FAN", "unknown"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{"rider42": "63b2dff9e834e550f0e50e66", "mx_fan": "63b2dff9e834e550f0e50e67"}, resolved)
+}
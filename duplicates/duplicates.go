@@ -0,0 +1,163 @@
+package duplicates
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"hash/fnv"
+	"math/bits"
+	"strings"
+	"unicode"
+)
+
+// Number of words in a shingle
+const shingleSize = 3
+
+// Fingerprint distance up to which texts count as near-duplicates
+const DefaultMaxDistance = 6
+
+// Normalize lower cases text, drops punctuation and symbols and collapses whitespace
+func Normalize(text string) string {
+	var b strings.Builder
+	space := false
+	for _, r := range strings.ToLower(text) {
+		switch {
+		case unicode.IsLetter(r) || unicode.IsNumber(r):
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		default:
+			space = true
+		}
+	}
+	return b.String()
+}
+
+// Hash returns the hex sha256 of the normalized text, texts that differ only in
+// case, spacing or punctuation hash equally
+func Hash(text string) string {
+	return hashNormalized(Normalize(text))
+}
+
+func hashNormalized(normalized string) string {
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
+
+// SimHash returns a 64 bit fingerprint of the word shingles of the normalized text,
+// near-duplicate texts have fingerprints that differ in few bits
+func SimHash(text string) uint64 {
+	var weights [64]int
+	for _, shingle := range shingles(strings.Fields(Normalize(text))) {
+		h := fnv.New64a()
+		h.Write([]byte(shingle))
+		sum := h.Sum64()
+		for i := 0; i < 64; i++ {
+			if sum&(1<<i) != 0 {
+				weights[i]++
+			} else {
+				weights[i]--
+			}
+		}
+	}
+
+	var fingerprint uint64
+	for i, weight := range weights {
+		if weight > 0 {
+			fingerprint |= 1 << i
+		}
+	}
+	return fingerprint
+}
+
+// Distance is the number of bits two fingerprints differ in
+func Distance(a uint64, b uint64) int {
+	return bits.OnesCount64(a ^ b)
+}
+
+// Similar reports whether two texts are equal after normalization, or are long enough
+// to shingle and have fingerprints at most maxDistance bits apart
+func Similar(a string, b string, maxDistance int) bool {
+	return newFingerprint(a).similar(newFingerprint(b), maxDistance)
+}
+
+// Cluster groups the indexes of similar texts, texts without a similar text are left out
+func Cluster(texts []string, maxDistance int) [][]int {
+	fingerprints := make([]fingerprint, len(texts))
+	for i, text := range texts {
+		fingerprints[i] = newFingerprint(text)
+	}
+
+	parent := make([]int, len(texts))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	for i := range fingerprints {
+		for j := i + 1; j < len(fingerprints); j++ {
+			if find(i) != find(j) && fingerprints[i].similar(fingerprints[j], maxDistance) {
+				parent[find(j)] = find(i)
+			}
+		}
+	}
+
+	// Keep the order of the texts, for clusters and their members
+	groups := make(map[int][]int)
+	var roots []int
+	for i := range texts {
+		root := find(i)
+		if _, ok := groups[root]; !ok {
+			roots = append(roots, root)
+		}
+		groups[root] = append(groups[root], i)
+	}
+
+	var clusters [][]int
+	for _, root := range roots {
+		if len(groups[root]) > 1 {
+			clusters = append(clusters, groups[root])
+		}
+	}
+	return clusters
+}
+
+type fingerprint struct {
+	hash    string
+	words   int
+	simHash uint64
+}
+
+func newFingerprint(text string) fingerprint {
+	normalized := Normalize(text)
+	return fingerprint{hash: hashNormalized(normalized), words: len(strings.Fields(normalized)), simHash: SimHash(normalized)}
+}
+
+// Texts too short to shingle are only similar when their hashes are equal
+func (f fingerprint) similar(other fingerprint, maxDistance int) bool {
+	if f.hash == other.hash {
+		return true
+	}
+	if f.words < shingleSize || other.words < shingleSize {
+		return false
+	}
+	return Distance(f.simHash, other.simHash) <= maxDistance
+}
+
+// Word shingles of words, a text shorter than a shingle is a single shingle
+func shingles(words []string) []string {
+	if len(words) < shingleSize {
+		return []string{strings.Join(words, " ")}
+	}
+	result := make([]string, 0, len(words)-shingleSize+1)
+	for i := 0; i+shingleSize <= len(words); i++ {
+		result = append(result, strings.Join(words[i:i+shingleSize], " "))
+	}
+	return result
+}
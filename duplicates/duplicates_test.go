@@ -0,0 +1,65 @@
+package duplicates
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// will test normalizing of text
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"case", "Doesn't WORK", "doesn t work"},
+		{"spacing", "  does   not\twork  ", "does not work"},
+		{"punctuation", "does not work!!!", "does not work"},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Normalize(test.text))
+		})
+	}
+}
+
+// will test similarity of texts
+func TestSimilar(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        string
+		b        string
+		expected bool
+	}{
+		{"exact", "Does not work", "does not work!", true},
+		{"short", "great mod", "great bike", false},
+		{"near", "the mod does not work after the latest update of the game, it crashes on load every time", "the mod does not work after the latest update of the game, it crashes on load every single time", true},
+		{"different", "the mod does not work after the latest update of the game", "the rear suspension feels too soft on the big jumps of this track", false},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Similar(test.a, test.b, 6))
+		})
+	}
+}
+
+// will test clustering of similar texts
+func TestCluster(t *testing.T) {
+	// Arrange
+	texts := []string{
+		"Does not work",
+		"the rear suspension feels too soft on the big jumps",
+		"does not work!",
+		"great track",
+		"DOES NOT WORK",
+	}
+
+	// Act
+	clusters := Cluster(texts, 3)
+
+	// Assert
+	assert.Equal(t, [][]int{{0, 2, 4}}, clusters)
+}
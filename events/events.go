@@ -0,0 +1,39 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Event types
+const (
+	TypeMention = "comment.mention"
+)
+
+type Event struct {
+	Type       string            `json:"type"`
+	UserID     string            `json:"userId"`
+	Attributes map[string]string `json:"attributes"`
+	OccurredAt time.Time         `json:"occurredAt"`
+}
+
+// Publisher delivers events to interested services
+type Publisher interface {
+	Publish(ctx context.Context, event Event) error
+}
+
+type logPublisher struct {
+	logger *logrus.Logger
+}
+
+// Return a publisher that writes every event to the log
+func NewLogPublisher(logger *logrus.Logger) *logPublisher {
+	return &logPublisher{logger: logger}
+}
+
+func (p *logPublisher) Publish(ctx context.Context, event Event) error {
+	p.logger.WithFields(logrus.Fields{"prefix": "EVENTS", "type": event.Type, "user": event.UserID}).Info(event.Attributes)
+	return nil
+}
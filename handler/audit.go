@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+func (e *Mod) QueryAuditLog(ctx context.Context, req *protobuffer.QueryAuditLogRequest) (*protobuffer.QueryAuditLogResponse, error) {
+	// The audit log holds client addresses and who changed what
+	caller := callerFromContext(ctx)
+	if caller.UserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if !caller.isAdmin() {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_QueryAuditLog"}).Errorf("caller {%s} is not an admin", caller.UserID)
+		return nil, status.Error(codes.PermissionDenied, "Error caller is not allowed to read the audit log!")
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	entries, count, err := e.repository.SearchAuditLog(ctx, models.AuditFilterFromProto(req), pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_QueryAuditLog"}).Infof("audit count: {%d} ", count)
+
+	return &protobuffer.QueryAuditLogResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Entries:    models.AuditLogsToProto(entries),
+	}, nil
+}
+
+// Record a mutation in the audit log along with the request it came from. The actor defaults to the caller,
+// before and after are the comment that changed, nil when it did not exist. Only a snapshot without
+// author and text is kept, the log is never erased.
+func (e *Mod) audit(ctx context.Context, caller caller, entry *models.AuditLog, before *models.Comment, after *models.Comment) error {
+	var err error
+	if entry.Before, err = models.AuditSnapshot(before); err != nil {
+		return err
+	}
+	if entry.After, err = models.AuditSnapshot(after); err != nil {
+		return err
+	}
+	return e.auditAll(ctx, caller, entry)
+}
+
+// Record mutations in the audit log in one append, the actor of every entry defaults to the caller
+func (e *Mod) auditAll(ctx context.Context, caller caller, entries ...*models.AuditLog) error {
+	for _, entry := range entries {
+		if entry.Actor == "" {
+			entry.Actor = caller.UserID
+		}
+		entry.RequestID = caller.RequestID
+		entry.ClientIP = caller.ClientIP
+	}
+	return e.repository.Audit(ctx, entries...)
+}
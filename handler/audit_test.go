@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"net"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Expect the first entry of an empty audit log appended on its own, snapshots and hash are not compared
+func expectAudit(mock sqlmock.Sqlmock, actor string, action string, target string, detail string) {
+	mock.ExpectBegin()
+	expectAuditEntry(mock, actor, action, target, detail)
+	mock.ExpectCommit()
+}
+
+// Expect the first entry of an empty audit log appended in the transaction of a change
+func expectAuditEntry(mock sqlmock.Sqlmock, actor string, action string, target string, detail string) {
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "sequence","hash" FROM "audit_logs" WHERE hash <> '' ORDER BY sequence DESC LIMIT 1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "hash"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_logs" ("sequence","actor","action","target","before","after","detail","request_id","client_ip","created_at","prev_hash","hash") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING "id"`)).
+		WithArgs(1, actor, action, target, sqlmock.AnyArg(), sqlmock.AnyArg(), detail, "", "", AnyTime{}, "", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewString()))
+}
+
+// will test query audit log without being authenticated
+func TestQueryAuditLogUnauthenticated(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.QueryAuditLog(NewCallerContext("", ""), &protobuffer.QueryAuditLogRequest{})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+// will test query audit log as moderator
+func TestQueryAuditLogPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.QueryAuditLog(NewCallerContext("moderator-1", "moderator"), &protobuffer.QueryAuditLogRequest{})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test query audit log filtered by actor and action
+func TestQueryAuditLog(t *testing.T) {
+	// Arrange
+	var entry = &models.AuditLog{
+		ID:        uuid.NewString(),
+		Sequence:  7,
+		Actor:     "moderator-1",
+		Action:    models.AuditActionHide,
+		Target:    uuid.NewString(),
+		RequestID: "req-1",
+		ClientIP:  "203.0.113.7",
+		CreatedAt: time.Now().UTC(),
+		Hash:      "ab",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "audit_logs" WHERE actor = $1 AND action = $2`)).
+		WithArgs(entry.Actor, entry.Action).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_logs" WHERE actor = $1 AND action = $2 ORDER BY sequence DESC LIMIT 20`)).
+		WithArgs(entry.Actor, entry.Action).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "sequence", "actor", "action", "target", "request_id", "client_ip", "created_at", "hash"}).
+			AddRow(entry.ID, entry.Sequence, entry.Actor, entry.Action, entry.Target, entry.RequestID, entry.ClientIP, entry.CreatedAt, entry.Hash))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	res, err := handler.QueryAuditLog(NewCallerContext("admin-1", "admin"), &protobuffer.QueryAuditLogRequest{Actor: entry.Actor, Action: entry.Action})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(1), res.Pagination.TotalCount)
+	assert.Len(t, res.Entries, 1)
+	assert.Equal(t, entry.Sequence, res.Entries[0].Sequence)
+	assert.Equal(t, entry.ClientIP, res.Entries[0].ClientIP)
+}
+
+// will test block user records the request id and forwarded client address
+func TestBlockUserAudited(t *testing.T) {
+	// Arrange
+	var callerID, userID = "63b2dff9e834e550f0e50e66", "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blocks" ("blocker_id","blocked_id","created_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`)).
+		WithArgs(callerID, userID, AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "sequence","hash" FROM "audit_logs" WHERE hash <> '' ORDER BY sequence DESC LIMIT 1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "hash"}).AddRow(41, "ab"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_logs"`)).
+		WithArgs(42, callerID, models.AuditActionBlock, userID, "", "", "", "req-1", "203.0.113.7", AnyTime{}, "ab", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewString()))
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+	ctx := peer.NewContext(context.Background(), &peer.Peer{Addr: &net.TCPAddr{IP: net.ParseIP("10.0.0.2"), Port: 50051}})
+	ctx = metadata.NewIncomingContext(ctx, metadata.Pairs(md_userID, callerID, md_requestID, "req-1", md_forwardedFor, "203.0.113.7, 10.0.0.1"))
+
+	// Act
+	_, err = handler.BlockUser(ctx, &protobuffer.BlockUserRequest{UserID: userID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,94 @@
+package handler
+
+import (
+	"context"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+func (e *Mod) BlockUser(ctx context.Context, req *protobuffer.BlockUserRequest) (*protobuffer.BlockUserResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := e.validateBlock(caller, req.UserID, "SERVICE.Comment_BlockUser"); err != nil {
+		return nil, err
+	}
+
+	err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Block(ctx, caller.UserID, req.UserID); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionBlock, Target: req.UserID}, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_BlockUser"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.BlockUserResponse{}, nil
+}
+
+func (e *Mod) UnblockUser(ctx context.Context, req *protobuffer.UnblockUserRequest) (*protobuffer.UnblockUserResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := e.validateBlock(caller, req.UserID, "SERVICE.Comment_UnblockUser"); err != nil {
+		return nil, err
+	}
+
+	err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Unblock(ctx, caller.UserID, req.UserID); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionUnblock, Target: req.UserID}, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UnblockUser"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.UnblockUserResponse{}, nil
+}
+
+func (e *Mod) ListBlocked(ctx context.Context, req *protobuffer.ListBlockedRequest) (*protobuffer.ListBlockedResponse, error) {
+	caller := callerFromContext(ctx)
+	if caller.UserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	blocks, count, err := e.repository.SearchBlocked(ctx, caller.UserID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ListBlocked"}).Infof(log_withUserID, caller.UserID)
+
+	return &protobuffer.ListBlockedResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Users:      models.BlocksToProto(blocks),
+	}, nil
+}
+
+// Blocks are always made by the authenticated caller for another user
+func (e *Mod) validateBlock(caller caller, userID string, prefix string) error {
+	if caller.UserID == "" {
+		return status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if userID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Error("request UserID is empty")
+		return status.Error(codes.InvalidArgument, "Error request value UserID, is required!")
+	}
+	if userID == caller.UserID {
+		return status.Error(codes.InvalidArgument, "Error request value UserID, can not be the caller!")
+	}
+	return nil
+}
+
+// Return the repository view of the caller, used to leave out blocked and shadow-banned authors
+func (c caller) viewer() repository.Viewer {
+	return repository.Viewer{UserID: c.UserID, Moderator: c.isModerator()}
+}
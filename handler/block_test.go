@@ -0,0 +1,92 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/directory"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test block user without authentication
+func TestBlockUserUnauthenticated(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.BlockUser(context.Background(), &protobuffer.BlockUserRequest{UserID: "63b2dff9e834e550f0e50e66"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+// will test block user of the caller itself
+func TestBlockUserSelf(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.BlockUser(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.BlockUserRequest{UserID: "63b2dff9e834e550f0e50e66"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test create comment mentioning a user that blocked the author
+func TestCreateCommentMentionBlocked(t *testing.T) {
+	// Arrange
+	newId := uuid.New()
+	blockerID := "63b2dff9e834e550f0e50e67"
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "@mx_fan try this",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blocker_id" FROM "blocks" WHERE blocked_id = $1 AND blocker_id IN ($2)`)).
+		WithArgs(request.UserID, blockerID).
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id"}).AddRow(blockerID))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "user_id" FROM "mentions" WHERE comment_id = $1`)).
+		WithArgs(newId.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	publisher := &recordingPublisher{}
+	users := directory.NewStaticDirectory(map[string]string{"mx_fan": blockerID})
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithUserDirectory(users), WithPublisher(publisher))
+
+	// Act
+	_, err = handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, len(publisher.events), 0)
+}
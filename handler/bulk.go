@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/gogo/status"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// Number of comments changed per transaction by a bulk moderation
+const bulkChunkSize = 500
+
+// Moderation of many comments at once, applied to one chunk of comments in a transaction
+type bulkAction struct {
+	prefix string
+	audit  string
+	apply  func(ctx context.Context, comments []*models.Comment) error
+}
+
+// Request of a bulk moderation, comments are selected by id or by filter
+type bulkRequest struct {
+	ids    []string
+	filter *protobuffer.CommentFilter
+	dryRun bool
+}
+
+func (e *Mod) BulkDeleteComments(ctx context.Context, req *protobuffer.BulkDeleteCommentsRequest) (*protobuffer.BulkDeleteCommentsResponse, error) {
+	action := bulkAction{prefix: "SERVICE.Comment_BulkDeleteComments", audit: models.AuditActionBulkDelete, apply: e.repository.DeleteComments}
+	matched, results, err := e.bulk(ctx, action, bulkRequest{ids: req.IDs, filter: req.Filter, dryRun: req.DryRun})
+	if err != nil {
+		return nil, err
+	}
+	return &protobuffer.BulkDeleteCommentsResponse{Matched: matched, Results: results}, nil
+}
+
+func (e *Mod) BulkHideComments(ctx context.Context, req *protobuffer.BulkHideCommentsRequest) (*protobuffer.BulkHideCommentsResponse, error) {
+	action := bulkAction{prefix: "SERVICE.Comment_BulkHideComments", audit: models.AuditActionBulkHide, apply: e.repository.HideComments}
+	matched, results, err := e.bulk(ctx, action, bulkRequest{ids: req.IDs, filter: req.Filter, dryRun: req.DryRun})
+	if err != nil {
+		return nil, err
+	}
+	return &protobuffer.BulkHideCommentsResponse{Matched: matched, Results: results}, nil
+}
+
+// Select the comments of a bulk request and apply the action in chunks. Returns the number of
+// matched comments and a result per comment, a dry run only counts the matches.
+func (e *Mod) bulk(ctx context.Context, action bulkAction, req bulkRequest) (int64, []*protobuffer.BulkResult, error) {
+	caller := callerFromContext(ctx)
+	if caller.UserID == "" {
+		return 0, nil, status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if (len(req.ids) == 0) == (req.filter == nil) {
+		e.logger.WithFields(logrus.Fields{"prefix": action.prefix}).Errorf("request needs either IDs or a Filter")
+		return 0, nil, status.Error(codes.InvalidArgument, "Error request needs either IDs or a Filter!")
+	}
+
+	var comments []*models.Comment
+	var results []*protobuffer.BulkResult
+	var detail string
+	var err error
+	if req.filter != nil {
+		filter := models.CommentFilterFromProto(req.filter)
+		if err := e.validateFilter(ctx, caller, filter, action.prefix); err != nil {
+			return 0, nil, err
+		}
+		if req.dryRun {
+			count, err := e.repository.CountByFilter(ctx, filter)
+			return count, nil, err
+		}
+		if comments, err = e.filteredComments(ctx, filter, action.prefix); err != nil {
+			return 0, nil, err
+		}
+		detail = filterDetail(filter)
+	} else if comments, results, err = e.selectedComments(ctx, caller, req.ids, action.prefix); err != nil {
+		return 0, nil, err
+	}
+
+	matched := int64(len(comments))
+	if req.dryRun || len(comments) == 0 {
+		return matched, results, nil
+	}
+
+	// Every changed comment gets its own entry, written with the chunk so the log can be searched by comment
+	var done int
+	for start := 0; start < len(comments); start += bulkChunkSize {
+		end := start + bulkChunkSize
+		if end > len(comments) {
+			end = len(comments)
+		}
+		chunk := comments[start:end]
+		err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+			if err := action.apply(ctx, chunk); err != nil {
+				return err
+			}
+			entries := make([]*models.AuditLog, 0, len(chunk))
+			for _, comment := range chunk {
+				entries = append(entries, &models.AuditLog{Action: action.audit, Target: comment.ID, Detail: detail})
+			}
+			return e.auditAll(ctx, caller, entries...)
+		})
+		if err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": action.prefix}).Errorf("unable to apply chunk of {%d} comments: %v", len(chunk), err)
+		} else {
+			done += len(chunk)
+		}
+		for _, comment := range chunk {
+			result := &protobuffer.BulkResult{ID: comment.ID, Done: err == nil}
+			if err != nil {
+				result.Error = "Error comment could not be changed!"
+			}
+			results = append(results, result)
+		}
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": action.prefix}).Infof("comments changed: {%d/%d}", done, matched)
+
+	return matched, results, nil
+}
+
+// Check a bulk filter is narrow, well formed and within the reach of the caller
+func (e *Mod) validateFilter(ctx context.Context, caller caller, filter models.CommentFilter, prefix string) error {
+	if !filter.Narrow() {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request filter selects by time only")
+		return status.Error(codes.InvalidArgument, "Error request value Filter, needs a UserID, ModID or TextPattern!")
+	}
+	if filter.ModID != "" {
+		if _, err := uuid.Parse(filter.ModID); err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request ModID is not a valid UUID: {%s}", filter.ModID)
+			return status.Error(codes.InvalidArgument, "Error request value ModID, is not a valid UUID!")
+		}
+	}
+	if len(filter.TextPattern) > models.MaxTextPatternLength {
+		return status.Error(codes.InvalidArgument, "Error request value TextPattern, is too long!")
+	}
+
+	// Owners may clean up their own mod, everything else is for moderators
+	if !caller.isModerator() && (filter.ModID == "" || !e.canModerate(ctx, caller, filter.ModID)) {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("caller {%s} is not allowed to moderate the filter", caller.UserID)
+		return status.Error(codes.PermissionDenied, "Error caller is not allowed to moderate this mod!")
+	}
+
+	// The pattern is matched by Postgres, whose syntax differs from Go
+	if filter.TextPattern == "" {
+		return nil
+	}
+	valid, err := e.repository.ValidTextPattern(ctx, filter.TextPattern)
+	if err != nil {
+		return err
+	}
+	if !valid {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request TextPattern is not a valid pattern: {%s}", filter.TextPattern)
+		return status.Error(codes.InvalidArgument, "Error request value TextPattern, is not a valid pattern!")
+	}
+	return nil
+}
+
+// Return the comments a filter selects, refusing filters that select more than a bulk moderation may change
+func (e *Mod) filteredComments(ctx context.Context, filter models.CommentFilter, prefix string) ([]*models.Comment, error) {
+	comments, err := e.repository.SearchByFilter(ctx, filter, models.MaxBulkComments+1)
+	if err != nil {
+		return nil, err
+	}
+	if len(comments) > models.MaxBulkComments {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request filter selects more than {%d} comments", models.MaxBulkComments)
+		return nil, status.Error(codes.FailedPrecondition, fmt.Sprintf("Error request value Filter, selects more than %d comments!", models.MaxBulkComments))
+	}
+	return comments, nil
+}
+
+// Describe a bulk filter for the audit log. The pattern may quote the text it matches, only its hash is kept.
+func filterDetail(filter models.CommentFilter) string {
+	var pattern string
+	if filter.TextPattern != "" {
+		pattern = models.TextHash(filter.TextPattern)
+	}
+	return fmt.Sprintf("user=%q mod=%q since=%s until=%s pattern_sha256=%s", filter.UserID, filter.ModID, filter.Since.Format(time.RFC3339), filter.Until.Format(time.RFC3339), pattern)
+}
+
+// Return the comments with the requested ids the caller may moderate, with a failed result for every other id
+func (e *Mod) selectedComments(ctx context.Context, caller caller, ids []string, prefix string) ([]*models.Comment, []*protobuffer.BulkResult, error) {
+	if len(ids) > models.MaxBulkIDs {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request IDs exceed maximum: {%d}", len(ids))
+		return nil, nil, status.Error(codes.InvalidArgument, fmt.Sprintf("Error request value IDs, has more than %d ids!", models.MaxBulkIDs))
+	}
+
+	var valid []string
+	seen := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if _, err := uuid.Parse(id); err == nil && !seen[id] {
+			valid = append(valid, id)
+		}
+		seen[id] = true
+	}
+
+	found := map[string]*models.Comment{}
+	if len(valid) > 0 {
+		comments, err := e.repository.FindByIDs(ctx, valid)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, comment := range comments {
+			found[comment.ID] = comment
+		}
+	}
+
+	var comments []*models.Comment
+	var results []*protobuffer.BulkResult
+	allowed := map[string]bool{}
+	reported := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		if reported[id] {
+			continue
+		}
+		reported[id] = true
+
+		if _, err := uuid.Parse(id); err != nil {
+			results = append(results, &protobuffer.BulkResult{ID: id, Error: "Error request value ID, is not a valid UUID!"})
+			continue
+		}
+		comment, ok := found[id]
+		if !ok {
+			results = append(results, &protobuffer.BulkResult{ID: id, Error: "Error comment not found!"})
+			continue
+		}
+		may, checked := allowed[comment.ModID]
+		if !checked {
+			may = e.canModerate(ctx, caller, comment.ModID)
+			allowed[comment.ModID] = may
+		}
+		if !may {
+			results = append(results, &protobuffer.BulkResult{ID: id, Error: "Error caller is not allowed to moderate this mod!"})
+			continue
+		}
+		comments = append(comments, comment)
+	}
+	return comments, results, nil
+}
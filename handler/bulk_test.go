@@ -0,0 +1,168 @@
+package handler
+
+import (
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test bulk delete without ids or filter
+func TestBulkDeleteCommentsWithoutSelection(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.BulkDeleteComments(NewCallerContext("moderator-1", "moderator"), &protobuffer.BulkDeleteCommentsRequest{})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test bulk delete with a filter that only selects a time range
+func TestBulkDeleteCommentsWideFilter(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.BulkDeleteComments(NewCallerContext("moderator-1", "moderator"), &protobuffer.BulkDeleteCommentsRequest{Filter: &protobuffer.CommentFilter{}})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test bulk hide by filter of a caller that is not a moderator
+func TestBulkHideCommentsNotModerator(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.BulkHideComments(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.BulkHideCommentsRequest{Filter: &protobuffer.CommentFilter{TextPattern: "cheap mods"}})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test the preview count of a bulk delete
+func TestBulkDeleteCommentsDryRun(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT '' ~* $1`)).
+		WithArgs("cheap\\s+mods").
+		WillReturnRows(sqlmock.NewRows([]string{"?column?"}).AddRow(false))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE user_id = $1 AND text ~* $2 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs("spammer", "cheap\\s+mods").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(42))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.BulkDeleteComments(NewCallerContext("moderator-1", "moderator"), &protobuffer.BulkDeleteCommentsRequest{
+		Filter: &protobuffer.CommentFilter{UserID: "spammer", TextPattern: "cheap\\s+mods"},
+		DryRun: true,
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Matched, int64(42))
+	assert.Empty(t, result.Results)
+}
+
+// Error of a pattern Postgres can not compile
+type invalidPatternError struct{}
+
+func (invalidPatternError) Error() string    { return "invalid regular expression" }
+func (invalidPatternError) SQLState() string { return "2201B" }
+
+// will test a bulk filter with a pattern Postgres rejects
+func TestBulkDeleteCommentsInvalidPattern(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT '' ~* $1`)).
+		WithArgs("(?<name>mods)").
+		WillReturnError(invalidPatternError{})
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.BulkDeleteComments(NewCallerContext("moderator-1", "moderator"), &protobuffer.BulkDeleteCommentsRequest{
+		Filter: &protobuffer.CommentFilter{UserID: "spammer", TextPattern: "(?<name>mods)"},
+	})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test bulk delete by ids with a result per id
+func TestBulkDeleteCommentsByIDs(t *testing.T) {
+	// Arrange
+	found, missing := uuid.NewString(), uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id IN ($1,$2) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(found, missing).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "ModID", "UserID", "Text"}).AddRow(found, uuid.NewString(), "spammer", "cheap mods"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "deleted_at"=$1 WHERE id IN ($2) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(AnyTime{}, found).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "comment_id" FROM "pins" WHERE comment_id IN ($1)`)).
+		WithArgs(found).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id"}))
+	expectAuditEntry(mock, "moderator-1", "comment.bulk_delete", found, "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.BulkDeleteComments(NewCallerContext("moderator-1", "moderator"), &protobuffer.BulkDeleteCommentsRequest{IDs: []string{found, "123", missing}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Matched, int64(1))
+	assert.Equal(t, len(result.Results), 3)
+	assert.Equal(t, result.Results[0].ID, "123")
+	assert.Equal(t, result.Results[1].ID, missing)
+	assert.Equal(t, result.Results[1].Error, "Error comment not found!")
+	assert.Equal(t, result.Results[2].ID, found)
+	assert.True(t, result.Results[2].Done)
+}
+
+// will test the audit detail of a bulk filter keeps the hash of its pattern instead of the pattern
+func TestFilterDetail(t *testing.T) {
+	// Arrange
+	filter := models.CommentFilter{UserID: "spammer", TextPattern: "call 555-0100"}
+
+	// Act
+	detail := filterDetail(filter)
+
+	// Assert
+	assert.NotContains(t, detail, filter.TextPattern)
+	assert.Contains(t, detail, "pattern_sha256="+models.TextHash(filter.TextPattern))
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"context"
+	"net"
+	"strings"
+
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// Metadata keys set by the gateway for the authenticated caller
+const (
+	md_userID         = "x-user-id"
+	md_roles          = "x-user-roles"
+	md_accountCreated = "x-account-created"
+	md_requestID      = "x-request-id"
+	md_forwardedFor   = "x-forwarded-for"
+)
+
+// Longest request id kept in the audit log
+const maxRequestIDLength = 100
+
+// Metadata key of the client chosen key that makes CreateComment safe to retry
+const md_idempotencyKey = "idempotency-key"
+
+// Caller roles
+const (
+	roleAdmin     = "admin"
+	roleModerator = "moderator"
+)
+
+type caller struct {
+	UserID    string
+	Roles     []string
+	RequestID string
+	ClientIP  string
+}
+
+// Return the authenticated caller of the request, empty when anonymous. The client is the first address
+// the gateway forwarded, or the peer when the request did not pass a gateway.
+func callerFromContext(ctx context.Context) caller {
+	var c caller
+	if p, ok := peer.FromContext(ctx); ok && p.Addr != nil {
+		if host, _, err := net.SplitHostPort(p.Addr.String()); err == nil && net.ParseIP(host) != nil {
+			c.ClientIP = host
+		}
+	}
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return c
+	}
+
+	if values := md.Get(md_forwardedFor); len(values) > 0 {
+		if ip := net.ParseIP(strings.TrimSpace(strings.Split(values[0], ",")[0])); ip != nil {
+			c.ClientIP = ip.String()
+		}
+	}
+	if values := md.Get(md_requestID); len(values) > 0 {
+		c.RequestID = strings.TrimSpace(values[0])
+		if len(c.RequestID) > maxRequestIDLength {
+			c.RequestID = c.RequestID[:maxRequestIDLength]
+		}
+	}
+	if values := md.Get(md_userID); len(values) > 0 {
+		c.UserID = strings.TrimSpace(values[0])
+	}
+	for _, value := range md.Get(md_roles) {
+		for _, role := range strings.Split(value, ",") {
+			if role = strings.TrimSpace(role); role != "" {
+				c.Roles = append(c.Roles, strings.ToLower(role))
+			}
+		}
+	}
+	return c
+}
+
+func (c caller) hasRole(role string) bool {
+	for _, r := range c.Roles {
+		if r == role {
+			return true
+		}
+	}
+	return false
+}
+
+func (c caller) isAdmin() bool {
+	return c.hasRole(roleAdmin)
+}
+
+func (c caller) isModerator() bool {
+	return c.hasRole(roleModerator) || c.isAdmin()
+}
@@ -2,29 +2,61 @@ package handler
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"github.com/go-playground/validator/v10"
 	"github.com/gogo/status"
 	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/directory"
+	"github.com/mxbikes/mxbikesclient.service.comment/duplicates"
+	"github.com/mxbikes/mxbikesclient.service.comment/events"
 	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/ownership"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
 	"github.com/mxbikes/mxbikesclient.service.comment/repository"
-	protobuffer "github.com/mxbikes/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
 	"github.com/sirupsen/logrus"
 	"google.golang.org/grpc/codes"
+	"gorm.io/gorm"
 )
 
 type Mod struct {
 	protobuffer.UnimplementedCommentServiceServer
-	repository repository.ModRepository
-	logger     logrus.Logger
-	validate   *validator.Validate
+	repository         repository.ModRepository
+	logger             *logrus.Logger
+	validate           *validator.Validate
+	textLimits         models.TextLimits
+	directory          directory.UserDirectory
+	publisher          events.Publisher
+	ownership          ownership.ModOwnership
+	preModeration      bool
+	trustedAfter       int
+	spam               *spam.Scorer
+	spamThresholds     spam.Thresholds
+	duplicateWindow    time.Duration
+	duplicateDistance  int
+	collapseDuplicates bool
+	idempotencyTTL     time.Duration
 }
 
 const log_withID = "mod with id: {%s} "
 
+// Bounds of a GetCommentsByModIDs request
+const (
+	maxModIDsPerRequest = 100
+	defaultPerModLimit  = 3
+	maxPerModLimit      = 50
+)
+
 // Return a new handler
-func New(postgres repository.ModRepository, logger logrus.Logger) *Mod {
-	return &Mod{repository: postgres, validate: validator.New(), logger: logger}
+func New(postgres repository.ModRepository, logger *logrus.Logger, opts ...Option) *Mod {
+	mod := &Mod{repository: postgres, logger: logger, textLimits: models.DefaultTextLimits(), publisher: events.NewLogPublisher(logger), duplicateDistance: duplicates.DefaultMaxDistance, idempotencyTTL: defaultIdempotencyTTL}
+	for _, opt := range opts {
+		opt(mod)
+	}
+	mod.validate = models.NewValidator(mod.textLimits)
+	return mod
 }
 
 func (e *Mod) GetCommentByModID(ctx context.Context, req *protobuffer.GetCommentByModIDRequest) (*protobuffer.GetCommentByModIDResponse, error) {
@@ -35,8 +67,13 @@ func (e *Mod) GetCommentByModID(ctx context.Context, req *protobuffer.GetComment
 		return nil, status.Error(codes.Internal, "Error request value ModID, is not a valid UUID!")
 	}
 
+	options, err := e.listOptions(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+
 	// Get Requested Comment
-	comments, err := e.repository.SearchByModID(req.ModID)
+	comments, err := e.repository.SearchByModID(ctx, req.ModID, options)
 	if err != nil {
 		return nil, err
 	}
@@ -46,12 +83,143 @@ func (e *Mod) GetCommentByModID(ctx context.Context, req *protobuffer.GetComment
 	return &protobuffer.GetCommentByModIDResponse{Comments: models.CommentsToProto(comments)}, nil
 }
 
+// Map the sorting and filters of a listing request to repository options
+func (e *Mod) listOptions(ctx context.Context, req *protobuffer.GetCommentByModIDRequest) (repository.ListOptions, error) {
+	caller := callerFromContext(ctx)
+	options := repository.ListOptions{UserID: req.UserID, IncludeHidden: req.IncludeHidden, Viewer: caller.viewer()}
+
+	switch req.Sort {
+	case protobuffer.SortMode_NEWEST:
+		options.Sort = repository.SortNewest
+	case protobuffer.SortMode_OLDEST:
+		options.Sort = repository.SortOldest
+	default:
+		return options, status.Error(codes.InvalidArgument, "Error request value Sort, is not valid!")
+	}
+
+	if req.Since != nil {
+		options.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		options.Until = req.Until.AsTime()
+	}
+	if !options.Since.IsZero() && !options.Until.IsZero() && !options.Since.Before(options.Until) {
+		return options, status.Error(codes.InvalidArgument, "Error request value Since, must be before Until!")
+	}
+
+	if options.IncludeHidden && !caller.isModerator() {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentByModID"}).Error("request IncludeHidden is only allowed for moderators")
+		return options, status.Error(codes.PermissionDenied, "Error request value IncludeHidden, is only allowed for moderators!")
+	}
+	return options, nil
+}
+
+func (e *Mod) GetCommentsByModIDs(ctx context.Context, req *protobuffer.GetCommentsByModIDsRequest) (*protobuffer.GetCommentsByModIDsResponse, error) {
+	if len(req.ModIDs) == 0 || len(req.ModIDs) > maxModIDsPerRequest {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByModIDs"}).Errorf("request ModIDs count is not valid: {%d}", len(req.ModIDs))
+		return nil, status.Errorf(codes.InvalidArgument, "Error request value ModIDs, must contain between 1 and %d ids!", maxModIDsPerRequest)
+	}
+	if req.Limit < 0 || req.Limit > maxPerModLimit {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByModIDs"}).Errorf("request Limit is not valid: {%d}", req.Limit)
+		return nil, status.Errorf(codes.InvalidArgument, "Error request value Limit, must be between 0 and %d!", maxPerModLimit)
+	}
+	limit := int(req.Limit)
+	if limit == 0 {
+		limit = defaultPerModLimit
+	}
+
+	// Check if valid uuids and drop duplicates
+	modIDs := make([]string, 0, len(req.ModIDs))
+	seen := make(map[string]bool, len(req.ModIDs))
+	for _, modID := range req.ModIDs {
+		if _, err := uuid.Parse(modID); err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByModIDs"}).Errorf("request ModID is not a valid UUID: {%s}", modID)
+			return nil, status.Error(codes.InvalidArgument, "Error request value ModIDs, contains an invalid UUID!")
+		}
+		if !seen[modID] {
+			seen[modID] = true
+			modIDs = append(modIDs, modID)
+		}
+	}
+
+	// Get Requested Comments
+	grouped, err := e.repository.SearchByModIDs(ctx, modIDs, limit, callerFromContext(ctx).viewer())
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*protobuffer.CommentList, len(modIDs))
+	for _, modID := range modIDs {
+		result[modID] = &protobuffer.CommentList{Comments: models.CommentsToProto(grouped[modID])}
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByModIDs"}).Infof("mods count: {%d} ", len(modIDs))
+
+	return &protobuffer.GetCommentsByModIDsResponse{Comments: result}, nil
+}
+
+func (e *Mod) GetCommentByID(ctx context.Context, req *protobuffer.GetCommentByIDRequest) (*protobuffer.GetCommentByIDResponse, error) {
+	// Check if valid uuid
+	_, err := uuid.Parse(req.ID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentByID"}).Errorf("request ID is not a valid UUID: {%s}", req.ID)
+		return nil, status.Error(codes.InvalidArgument, "Error request value ID, is not a valid UUID!")
+	}
+
+	// Get Requested Comment
+	caller := callerFromContext(ctx)
+	comment, err := e.repository.FindVisibleByID(ctx, req.ID, caller.viewer())
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "Error comment not found!")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Hidden comments are only visible to their author and moderators
+	if comment.Hidden && caller.UserID != comment.UserID && !caller.isModerator() {
+		return nil, status.Error(codes.NotFound, "Error comment not found!")
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentByID"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.GetCommentByIDResponse{Comment: models.CommentToProto(comment)}, nil
+}
+
+func (e *Mod) GetCommentsByUserID(ctx context.Context, req *protobuffer.GetCommentsByUserIDRequest) (*protobuffer.GetCommentsByUserIDResponse, error) {
+	if req.UserID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByUserID"}).Error("request UserID is empty")
+		return nil, status.Error(codes.InvalidArgument, "Error request value UserID, is required!")
+	}
+	if req.ModID != "" {
+		if _, err := uuid.Parse(req.ModID); err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByUserID"}).Errorf("request ModID is not a valid UUID: {%s}", req.ModID)
+			return nil, status.Error(codes.InvalidArgument, "Error request value ModID, is not a valid UUID!")
+		}
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	// Get Requested Comments
+	comments, count, err := e.repository.SearchByUserID(ctx, req.UserID, req.ModID, pagination, callerFromContext(ctx).viewer())
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_GetCommentsByUserID"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.GetCommentsByUserIDResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Comments:   models.CommentsToProto(comments),
+	}, nil
+}
+
 func (e *Mod) UpdateComment(ctx context.Context, req *protobuffer.UpdateCommentRequest) (*protobuffer.UpdateCommentResponse, error) {
+	caller := callerFromContext(ctx)
 	comment := &models.Comment{
 		ID:     req.ID,
 		ModID:  req.ModID,
 		UserID: req.UserID,
-		Text:   req.Text,
+		Text:   models.NormalizeText(req.Text),
 	}
 
 	// Validate
@@ -61,26 +229,109 @@ func (e *Mod) UpdateComment(ctx context.Context, req *protobuffer.UpdateCommentR
 		return nil, err.(validator.ValidationErrors)
 	}
 
-	// Get Requested Comment
-	err = e.repository.Save(comment)
+	// The version the caller read is required, an edit never overwrites blindly
+	if req.Version == 0 {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Errorf("request version is missing: {%s}", req.ID)
+		return nil, status.Error(codes.InvalidArgument, "Error request value Version, is required!")
+	}
+
+	// Only the author or a moderator of the mod may edit
+	existing, err := e.authorizedComment(ctx, caller, req.ID, true, "SERVICE.Comment_UpdateComment")
+	if err != nil {
+		return nil, err
+	}
+
+	// Only the text of a comment can change
+	if existing.ModID != comment.ModID || existing.UserID != comment.UserID {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Errorf("request does not match comment: {%s}", req.ID)
+		return nil, status.Error(codes.InvalidArgument, "Error request value ModID or UserID, does not match the comment!")
+	}
+
+	// Refuse to overwrite a change the caller has not seen
+	if req.Version != existing.Version {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Errorf("request version is stale: {%d} current {%d}", req.Version, existing.Version)
+		return nil, status.Error(codes.FailedPrecondition, "Error request value Version, the comment was changed by another request!")
+	}
+
+	changes := map[string]interface{}{}
+	if existing.Text != comment.Text {
+		changes["text"] = comment.Text
+	}
+	if len(changes) == 0 {
+		return &protobuffer.UpdateCommentResponse{Version: existing.Version}, nil
+	}
+
+	// Edited text is scored and held for review again, like the text of a new comment
+	if existing.Status == "" || existing.Status == models.StatusPublished {
+		comment.Status = e.spamStatus(ctx, comment)
+		if comment.Status == "" {
+			pending, err := e.requiresReview(ctx, comment)
+			if err != nil {
+				return nil, err
+			}
+			if pending {
+				comment.Status = models.StatusPending
+			}
+		}
+		if comment.Status != "" {
+			changes["status"] = comment.Status
+		}
+		if comment.SpamScore != nil {
+			changes["spam_score"] = *comment.SpamScore
+		}
+	}
+	before := *existing
+
+	entry := &models.AuditLog{Action: models.AuditActionUpdate, Target: existing.ID}
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Update(ctx, existing, changes); err != nil {
+			return err
+		}
+		existing.Text = comment.Text
+		if comment.Status != "" {
+			existing.Status = comment.Status
+		}
+		if comment.SpamScore != nil {
+			existing.SpamScore = comment.SpamScore
+		}
+		return e.audit(ctx, caller, entry, &before, existing)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Errorf("comment not found: {%s}", req.ID)
+		return nil, status.Error(codes.NotFound, "Error comment not found!")
+	}
+	if errors.Is(err, repository.ErrVersionConflict) {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Errorf("comment was changed concurrently: {%s}", req.ID)
+		return nil, status.Error(codes.FailedPrecondition, "Error request value Version, the comment was changed by another request!")
+	}
 	if err != nil {
 		return nil, err
 	}
 
+	// Comments waiting for review notify nobody until they are approved
+	if existing.Status == "" || existing.Status == models.StatusPublished {
+		e.syncMentions(ctx, existing, false)
+	}
+
 	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UpdateComment"}).Infof(log_withID, comment.ModID)
 
-	return &protobuffer.UpdateCommentResponse{}, nil
+	return &protobuffer.UpdateCommentResponse{Version: existing.Version}, nil
 }
 
 func (e *Mod) DeleteComment(ctx context.Context, req *protobuffer.DeleteCommentRequest) (*protobuffer.DeleteCommentResponse, error) {
-	// Validate
-	_, err := uuid.Parse(req.ID)
+	// Only the author or a moderator of the mod may delete, anonymous requests are refused
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, true, "SERVICE.Comment_DeleteComment")
 	if err != nil {
-		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_DeleteComment"}).Errorf("request ID is not a valid UUID: {%s}", req.ID)
-		return nil, status.Error(codes.Internal, "Error request value ID, is not a valid UUID!")
+		return nil, err
 	}
 
-	err = e.repository.Delete(req.ID)
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Delete(ctx, req.ID); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionDelete, Target: req.ID}, comment, nil)
+	})
 	if err != nil {
 		return nil, err
 	}
@@ -94,7 +345,7 @@ func (e *Mod) CreateComment(ctx context.Context, req *protobuffer.CreateCommentR
 	comment := &models.Comment{
 		ModID:  req.ModID,
 		UserID: req.UserID,
-		Text:   req.Text,
+		Text:   models.NormalizeText(req.Text),
 	}
 
 	// Validate
@@ -104,13 +355,68 @@ func (e *Mod) CreateComment(ctx context.Context, req *protobuffer.CreateCommentR
 		return nil, err.(validator.ValidationErrors)
 	}
 
+	// Anonymous requests read their writes as the author, the audit log records them without an actor
+	caller := callerFromContext(ctx)
+	entry := &models.AuditLog{Action: models.AuditActionCreate}
+	if caller.UserID == "" {
+		ctx = repository.WithUser(ctx, comment.UserID)
+	}
+
+	// Answer a retried request with its earlier result
+	key, response, err := e.reserveIdempotencyKey(ctx, comment)
+	if err != nil || response != nil {
+		return response, err
+	}
+
+	response, err = e.createComment(ctx, caller, comment, entry)
+	e.completeIdempotencyKey(key, response, err)
+	return response, err
+}
+
+func (e *Mod) createComment(ctx context.Context, caller caller, comment *models.Comment, entry *models.AuditLog) (*protobuffer.CreateCommentResponse, error) {
+	// Reject repeated comments of the author, or answer with the earlier comment
+	duplicate, err := e.findDuplicate(ctx, comment)
+	if err != nil {
+		return nil, err
+	}
+	if duplicate != nil {
+		if !e.collapseDuplicates {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("request duplicates comment: {%s}", duplicate.ID)
+			return nil, status.Error(codes.AlreadyExists, "Error comment duplicates an earlier comment!")
+		}
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Infof("collapsed into comment: {%s}", duplicate.ID)
+		return &protobuffer.CreateCommentResponse{ID: duplicate.ID, Status: models.StatusToProto(duplicate.Status)}, nil
+	}
+
+	// Reject spam or hold it for review, like every comment of a pre-moderated mod
+	comment.Status = e.spamStatus(ctx, comment)
+	if comment.Status == "" {
+		pending, err := e.requiresReview(ctx, comment)
+		if err != nil {
+			return nil, err
+		}
+		if pending {
+			comment.Status = models.StatusPending
+		}
+	}
+
 	// Get Requested Comment
-	err = e.repository.Save(comment)
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Create(ctx, comment); err != nil {
+			return err
+		}
+		entry.Target = comment.ID
+		return e.audit(ctx, caller, entry, nil, comment)
+	})
 	if err != nil {
 		return nil, err
 	}
 
+	if comment.Status == "" {
+		e.syncMentions(ctx, comment, true)
+	}
+
 	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Infof(log_withID, comment.ID)
 
-	return &protobuffer.CreateCommentResponse{ID: comment.ID}, nil
+	return &protobuffer.CreateCommentResponse{ID: comment.ID, Status: models.StatusToProto(comment.Status)}, nil
 }
@@ -12,10 +12,13 @@ import (
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
 	"github.com/mxbikes/mxbikesclient.service.comment/repository"
-	protobuffer "github.com/mxbikes/protobuf/comment"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
@@ -39,7 +42,7 @@ func NewDefaultHandler() *Mod {
 	}
 
 	repo := repository.NewRepository(gdb)
-	return New(repo, *logrus.New())
+	return New(repo, logrus.New())
 }
 
 // will test get comment by modId empty uuid
@@ -72,11 +75,14 @@ func TestGetCommentByModID(t *testing.T) {
 	var modID = uuid.New()
 
 	db, mock := NewMock()
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND "comments"."deleted_at" IS NULL`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC`)).
 		WithArgs(modID).
 		WillReturnRows(sqlmock.
 			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
 			AddRow(uuid.New().String(), modID.String(), uuid.New().String(), "Good Job!"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(modID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}))
 
 	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
 	if err != nil {
@@ -84,7 +90,7 @@ func TestGetCommentByModID(t *testing.T) {
 	}
 
 	repo := repository.NewRepository(gdb)
-	handler := New(repo, *logrus.New())
+	handler := New(repo, logrus.New())
 
 	// Act
 	result, err := handler.GetCommentByModID(context.Background(), &protobuffer.GetCommentByModIDRequest{ModID: modID.String()})
@@ -92,6 +98,131 @@ func TestGetCommentByModID(t *testing.T) {
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, result.Comments[0].ModID, modID.String())
+	assert.Equal(t, result.Comments[0].HTML, "<p>Good Job!</p>")
+}
+
+// will test get comment by modId including hidden comments without being a moderator
+func TestGetCommentByModIDIncludeHiddenPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.GetCommentByModID(context.Background(), &protobuffer.GetCommentByModIDRequest{ModID: uuid.NewString(), IncludeHidden: true})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test get comment by modId with a sort mode that does not exist
+func TestGetCommentByModIDSortInvalid(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.GetCommentByModID(context.Background(), &protobuffer.GetCommentByModIDRequest{ModID: uuid.NewString(), Sort: protobuffer.SortMode(2)})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test get comments by modIds with an invalid uuid
+func TestGetCommentsByModIDsWrongUUID(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.GetCommentsByModIDs(context.Background(), &protobuffer.GetCommentsByModIDsRequest{ModIDs: []string{uuid.NewString(), "123"}})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test get comments by modIds
+func TestGetCommentsByModIDs(t *testing.T) {
+	// Arrange
+	var modA, modB = uuid.NewString(), uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY mod_id ORDER BY created_at DESC) AS row_rank FROM "comments" WHERE mod_id IN ($1,$2) AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL) AS ranked WHERE row_rank <= $3 ORDER BY mod_id, row_rank`)).
+		WithArgs(modA, modB, defaultPerModLimit).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(uuid.NewString(), modA, uuid.NewString(), "Good Job!"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	result, err := handler.GetCommentsByModIDs(context.Background(), &protobuffer.GetCommentsByModIDsRequest{ModIDs: []string{modA, modB, modA}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(result.Comments), 2)
+	assert.Equal(t, len(result.Comments[modA].Comments), 1)
+	assert.Empty(t, result.Comments[modB].Comments)
+}
+
+// will test get comment by id that does not exist
+func TestGetCommentByIDNotFound(t *testing.T) {
+	// Arrange
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "ModID", "UserID", "Text"}))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	_, err = handler.GetCommentByID(context.Background(), &protobuffer.GetCommentByIDRequest{ID: commentID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.NotFound)
+}
+
+// will test get comments by userId
+func TestGetCommentsByUserID(t *testing.T) {
+	// Arrange
+	var userID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE user_id = $1 AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE user_id = $1 AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC LIMIT 20`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(uuid.NewString(), uuid.NewString(), userID, "Good Job!"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	result, err := handler.GetCommentsByUserID(context.Background(), &protobuffer.GetCommentsByUserIDRequest{UserID: userID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, result.Pagination.TotalPages, int64(1))
+	assert.False(t, result.Pagination.HasMore)
+	assert.Equal(t, result.Comments[0].UserID, userID)
 }
 
 // will test update comment with wrong uuid
@@ -129,7 +260,7 @@ func TestUpdateCommentValidationTextExceedMaxFailed(t *testing.T) {
 	_, err := handler.UpdateComment(context.Background(), request)
 
 	// Assert
-	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'max' tag").Error())
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'textmax' tag").Error())
 }
 
 // will test update comment with min text
@@ -148,11 +279,88 @@ func TestUpdateCommentValidationTextExceedMinFailed(t *testing.T) {
 	_, err := handler.UpdateComment(context.Background(), request)
 
 	// Assert
-	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'min' tag").Error())
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'textmin' tag").Error())
 }
 
-// will test update comment
+// will test update comment writing only the changed text
 func TestUpdateComment(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"version"=version + 1,"updated_at"=$2 WHERE (id = $3 AND version = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(request.Text, AnyTime{}, request.ID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntry(mock, request.UserID, models.AuditActionUpdate, request.ID, "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	result, err := handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Version, int64(3))
+}
+
+// will test an edited comment on a pre-moderated mod goes back to the review queue
+func TestUpdateCommentPreModerated(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}).AddRow(request.ModID, true))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "status"=$1,"text"=$2,"version"=version + 1,"updated_at"=$3 WHERE (id = $4 AND version = $5) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(models.StatusPending, request.Text, AnyTime{}, request.ID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntry(mock, request.UserID, models.AuditActionUpdate, request.ID, "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Version, int64(3))
+}
+
+// will test update comment without the version the caller read
+func TestUpdateCommentVersionMissing(t *testing.T) {
 	// Arrange
 	request := &protobuffer.UpdateCommentRequest{
 		ID:     uuid.NewString(),
@@ -161,41 +369,274 @@ func TestUpdateComment(t *testing.T) {
 		Text:   "comment 4",
 	}
 
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test update of an unknown comment
+func TestUpdateCommentNotFound(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
+
 	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(request.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.NotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update comment with a stale version
+func TestUpdateCommentStaleVersion(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 1,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update comment changed by a concurrent request
+func TestUpdateCommentConcurrentChange(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
 
+	db, mock := newUpdateMock(request, 2)
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}))
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "created_at"=$1,"updated_at"=$2,"deleted_at"=$3,"mod_id"=$4,"user_id"=$5,"text"=$6 WHERE "comments"."deleted_at" IS NULL AND "id" = $7`)).
-		WithArgs(AnyTime{}, AnyTime{}, nil, request.ModID, request.UserID, request.Text, request.ID).
-		WillReturnResult(sqlmock.NewResult(0, 1))
-	mock.ExpectCommit()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"version"=version + 1,"updated_at"=$2 WHERE (id = $3 AND version = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(request.Text, AnyTime{}, request.ID, 2).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(request.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectRollback()
 
 	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
 	if err != nil {
 		log.Fatalf(log_failedConn, err)
 	}
 
-	repo := repository.NewRepository(gdb)
-	handler := New(repo, *logrus.New())
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update comment without changes
+func TestUpdateCommentUnchanged(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 1",
+		Version: 2,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
 
 	// Act
-	result, err := handler.UpdateComment(context.Background(), request)
+	result, err := handler.UpdateComment(NewCallerContext(request.UserID, ""), request)
 
 	// Assert
 	assert.NoError(t, err)
-	assert.NotNil(t, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Version, int64(2))
+}
+
+// will test update comment without being authenticated
+func TestUpdateCommentUnauthenticated(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
+
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.UpdateComment(context.Background(), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+// will test update comment of another user without moderating the mod
+func TestUpdateCommentNotAuthor(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 4",
+		Version: 2,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.UpdateComment(NewCallerContext(uuid.NewString(), ""), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update comment of another user by a moderator
+func TestUpdateCommentModerator(t *testing.T) {
+	// Arrange
+	request := &protobuffer.UpdateCommentRequest{
+		ID:      uuid.NewString(),
+		ModID:   uuid.NewString(),
+		UserID:  uuid.NewString(),
+		Text:    "comment 1",
+		Version: 2,
+	}
+
+	db, mock := newUpdateMock(request, 2)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.UpdateComment(NewCallerContext("moderator-1", "moderator"), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Version, int64(2))
+}
+
+func newUpdateMock(request *protobuffer.UpdateCommentRequest, version int64) (*sql.DB, sqlmock.Sqlmock) {
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(request.ID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "Status", "Version"}).
+			AddRow(request.ID, request.ModID, request.UserID, "comment 1", "published", version))
+	return db, mock
+}
+
+// will test delete comment with wrong uuid
+func TestDeleteCommentValidationUuidFailed(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.DeleteComment(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.DeleteCommentRequest{ID: "23456"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test delete comment without being authenticated
+func TestDeleteCommentUnauthenticated(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.DeleteComment(context.Background(), &protobuffer.DeleteCommentRequest{ID: uuid.NewString()})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
 }
 
 // will test delete comment
 func TestDeleteComment(t *testing.T) {
 	// Arrange
 	commentID := uuid.New()
+	userID := "63b2dff9e834e550f0e50e66"
 
 	db, mock := NewMock()
 
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID.String()).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(commentID.String(), uuid.NewString(), userID, "Good Job!"))
 	mock.ExpectBegin()
 	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "deleted_at"=$1 WHERE "comments"."id" = $2 AND "comments"."deleted_at" IS NULL`)).
 		WithArgs(AnyTime{}, commentID).
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "pins" WHERE comment_id = $1 RETURNING *`)).
+		WithArgs(commentID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}))
+	expectAuditEntry(mock, userID, models.AuditActionDelete, commentID.String(), "")
 	mock.ExpectCommit()
 
 	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
@@ -204,15 +645,42 @@ func TestDeleteComment(t *testing.T) {
 	}
 
 	repo := repository.NewRepository(gdb)
-	handler := New(repo, *logrus.New())
+	handler := New(repo, logrus.New())
 
 	// Act
-	_, err = handler.DeleteComment(context.Background(), &protobuffer.DeleteCommentRequest{ID: commentID.String()})
+	_, err = handler.DeleteComment(NewCallerContext(userID, ""), &protobuffer.DeleteCommentRequest{ID: commentID.String()})
 
 	// Assert
 	assert.NoError(t, err)
 }
 
+// will test delete comment of another user
+func TestDeleteCommentPermissionDenied(t *testing.T) {
+	// Arrange
+	commentID := uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(commentID, uuid.NewString(), "63b2dff9e834e550f0e50e66", "Good Job!"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	_, err = handler.DeleteComment(NewCallerContext("other", ""), &protobuffer.DeleteCommentRequest{ID: commentID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
 // will test create comment with wrong uuid
 func TestCreateCommentValidationUuidFailed(t *testing.T) {
 	// Arrange
@@ -246,7 +714,7 @@ func TestCreateCommentValidationTextExceedMaxFailed(t *testing.T) {
 	_, err := handler.CreateComment(context.Background(), request)
 
 	// Assert
-	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'max' tag").Error())
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'textmax' tag").Error())
 }
 
 // will test update comment with min text
@@ -264,7 +732,68 @@ func TestCreateCommentValidationTextExceedMinFailed(t *testing.T) {
 	_, err := handler.CreateComment(context.Background(), request)
 
 	// Assert
-	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'min' tag").Error())
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'textmin' tag").Error())
+}
+
+// will test create comment with only zero width characters
+func TestCreateCommentValidationTextInvisibleFailed(t *testing.T) {
+	// Arrange
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: uuid.NewString(),
+		Text:   "\u200b\u200d\u2060",
+	}
+
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'visible' tag").Error())
+}
+
+// will test create comment with configured length limits counting emoji as one character
+func TestCreateCommentValidationTextLimits(t *testing.T) {
+	// Arrange
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: uuid.NewString(),
+		Text:   "  \U0001F468\u200d\U0001F469\u200d\U0001F467\U0001F3CD\U0001F3FD\U0001F525  ",
+	}
+
+	db, _ := NewMock()
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New(), WithTextLimits(models.TextLimits{Min: 4, Max: 10}))
+
+	// Act
+	_, err = handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'textmin' tag").Error())
+}
+
+// will test create comment with an unsafe markdown link
+func TestCreateCommentValidationMarkdownFailed(t *testing.T) {
+	// Arrange
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: uuid.NewString(),
+		Text:   "[click](javascript:alert(1))",
+	}
+
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.Equal(t, err.Error(), errors.New("Key: 'Comment.Text' Error:Field validation for 'Text' failed on the 'markdown' tag").Error())
 }
 
 // will test update comment
@@ -279,10 +808,14 @@ func TestCreateComment(t *testing.T) {
 
 	db, mock := NewMock()
 
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}))
 	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id","user_id","text"`)).
 		WithArgs(AnyTime{}, AnyTime{}, nil, request.ModID, request.UserID, request.Text).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
 	mock.ExpectCommit()
 
 	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
@@ -291,13 +824,14 @@ func TestCreateComment(t *testing.T) {
 	}
 
 	repo := repository.NewRepository(gdb)
-	handler := New(repo, *logrus.New())
+	handler := New(repo, logrus.New())
 
 	// Act
 	result, err := handler.CreateComment(context.Background(), request)
 
 	// Assert
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
 	assert.Equal(t, result.ID, newId.String())
 }
 
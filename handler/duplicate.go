@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/duplicates"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// Bounds of duplicate detection
+const (
+	maxDuplicateCandidates = 100
+	maxClusterComments     = 5000
+	defaultClusterWindow   = 7 * 24 * time.Hour
+)
+
+func (e *Mod) ListDuplicateClusters(ctx context.Context, req *protobuffer.ListDuplicateClustersRequest) (*protobuffer.ListDuplicateClustersResponse, error) {
+	// Clusters over every mod are for moderators, clusters of one mod also for its owner
+	caller := callerFromContext(ctx)
+	if req.ModID == "" {
+		if err := e.authorizeModerator(caller, "SERVICE.Comment_ListDuplicateClusters"); err != nil {
+			return nil, err
+		}
+	} else if err := e.authorizeMod(ctx, caller, req.ModID, "SERVICE.Comment_ListDuplicateClusters"); err != nil {
+		return nil, err
+	}
+
+	since := time.Now().Add(-defaultClusterWindow)
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	var until time.Time
+	if req.Until != nil {
+		until = req.Until.AsTime()
+		if !since.Before(until) {
+			return nil, status.Error(codes.InvalidArgument, "Error request value Since, must be before Until!")
+		}
+	}
+
+	// Owners only cluster the comments they can see, moderators every comment
+	var viewer *repository.Viewer
+	if !caller.isModerator() {
+		viewer = &repository.Viewer{}
+	}
+	comments, err := e.repository.SearchByCreatedAt(ctx, req.ModID, req.UserID, since, until, maxClusterComments+1, viewer)
+	if err != nil {
+		return nil, err
+	}
+	truncated := len(comments) > maxClusterComments
+	if truncated {
+		comments = comments[:maxClusterComments]
+	}
+
+	texts := make([]string, len(comments))
+	for i, comment := range comments {
+		texts[i] = comment.Text
+	}
+	var clusters []*protobuffer.DuplicateCluster
+	for _, indexes := range duplicates.Cluster(texts, e.duplicateDistance) {
+		cluster := make([]*models.Comment, 0, len(indexes))
+		for _, i := range indexes {
+			cluster = append(cluster, comments[i])
+		}
+		clusters = append(clusters, &protobuffer.DuplicateCluster{Comments: models.CommentsToProto(cluster)})
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ListDuplicateClusters"}).Infof("clusters count: {%d} ", len(clusters))
+
+	return &protobuffer.ListDuplicateClustersResponse{Clusters: clusters, Truncated: truncated}, nil
+}
+
+// Return the recent comment of the author on the same mod that a new comment repeats, nil when none or detection is off.
+// Repeats on other mods are left to the spam scorer.
+func (e *Mod) findDuplicate(ctx context.Context, comment *models.Comment) (*models.Comment, error) {
+	if e.duplicateWindow <= 0 {
+		return nil, nil
+	}
+
+	recent, err := e.repository.SearchByCreatedAt(ctx, comment.ModID, comment.UserID, time.Now().Add(-e.duplicateWindow), time.Time{}, maxDuplicateCandidates, nil)
+	if err != nil {
+		return nil, err
+	}
+	for _, earlier := range recent {
+		if duplicates.Similar(earlier.Text, comment.Text, e.duplicateDistance) {
+			return earlier, nil
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,135 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/ownership"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newDuplicateMock(request *protobuffer.CreateCommentRequest, earlierID string) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE created_at >= $1 AND mod_id = $2 AND user_id = $3 AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC LIMIT 100`)).
+		WithArgs(AnyTime{}, request.ModID, request.UserID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "Status"}).
+			AddRow(earlierID, request.ModID, request.UserID, "Doesn't work!", "published"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	return gdb, mock
+}
+
+// will test create comment repeating an earlier comment of the author
+func TestCreateCommentDuplicate(t *testing.T) {
+	// Arrange
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "DOESN'T   work",
+	}
+	gdb, mock := newDuplicateMock(request, uuid.NewString())
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithDuplicateDetection(time.Hour, 6, false))
+
+	// Act
+	_, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.AlreadyExists)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test create comment collapsed into an earlier comment of the author
+func TestCreateCommentDuplicateCollapsed(t *testing.T) {
+	// Arrange
+	earlierID := uuid.NewString()
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "doesn't work",
+	}
+	gdb, mock := newDuplicateMock(request, earlierID)
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithDuplicateDetection(time.Hour, 6, true))
+
+	// Act
+	result, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.ID, earlierID)
+}
+
+// will test list duplicate clusters of a mod
+func TestListDuplicateClusters(t *testing.T) {
+	// Arrange
+	var modID = uuid.NewString()
+	var first, second = uuid.NewString(), uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE created_at >= $1 AND mod_id = $2 AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC LIMIT 5001`)).
+		WithArgs(AnyTime{}, modID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(first, modID, "63b2dff9e834e550f0e50e66", "Doesn't work").
+			AddRow(uuid.NewString(), modID, "63b2dff9e834e550f0e50e67", "Great track").
+			AddRow(second, modID, "63b2dff9e834e550f0e50e68", "doesn't work!!"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.ListDuplicateClusters(NewCallerContext("moderator-1", "moderator"), &protobuffer.ListDuplicateClustersRequest{ModID: modID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(result.Clusters), 1)
+	assert.Equal(t, result.Clusters[0].Comments[0].ID, first)
+	assert.Equal(t, result.Clusters[0].Comments[1].ID, second)
+}
+
+// will test the duplicate clusters of a mod owner leave out comments hidden from the public
+func TestListDuplicateClustersOwner(t *testing.T) {
+	// Arrange
+	var modID, ownerID = uuid.NewString(), "63b2dff9e834e550f0e50e69"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE created_at >= $1 AND mod_id = $2 AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC LIMIT 5001`)).
+		WithArgs(AnyTime{}, modID).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "ModID", "UserID", "Text"}))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	owners := ownership.NewStaticOwnership(map[string]string{modID: ownerID})
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithModOwnership(owners))
+
+	// Act
+	result, err := handler.ListDuplicateClusters(NewCallerContext(ownerID, ""), &protobuffer.ListDuplicateClustersRequest{ModID: modID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Empty(t, result.Clusters)
+}
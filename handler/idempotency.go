@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/gorm"
+)
+
+// Time an idempotency key is remembered when not configured
+const defaultIdempotencyTTL = 24 * time.Hour
+
+// Return the idempotency key sent with the request, empty when there is none
+func idempotencyKeyFromContext(ctx context.Context) string {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return ""
+	}
+	if values := md.Get(md_idempotencyKey); len(values) > 0 {
+		return strings.TrimSpace(values[0])
+	}
+	return ""
+}
+
+// Fingerprint of the comment a request creates, a key may only be reused for the same comment
+func requestHash(comment *models.Comment) string {
+	hash := sha256.New()
+	for _, field := range []string{comment.ModID, comment.UserID, comment.Text} {
+		hash.Write([]byte(field))
+		hash.Write([]byte{0})
+	}
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Reserve the idempotency key of the request before the comment is created. When the key
+// was used before the earlier response is returned instead, without a key both are nil.
+func (e *Mod) reserveIdempotencyKey(ctx context.Context, comment *models.Comment) (*models.IdempotencyKey, *protobuffer.CreateCommentResponse, error) {
+	name := idempotencyKeyFromContext(ctx)
+	if name == "" || e.idempotencyTTL <= 0 {
+		return nil, nil, nil
+	}
+	if len(name) > models.MaxIdempotencyKeyLength {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("request idempotency key is too long: {%d}", len(name))
+		return nil, nil, status.Error(codes.InvalidArgument, "Error request idempotency key, is too long!")
+	}
+
+	// Keys are scoped to the caller, anonymous requests to the author
+	callerID := callerFromContext(ctx).UserID
+	if callerID == "" {
+		callerID = comment.UserID
+	}
+
+	now := time.Now()
+	key := &models.IdempotencyKey{
+		CallerID:    callerID,
+		Key:         name,
+		RequestHash: requestHash(comment),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(e.idempotencyTTL),
+	}
+	reserved, err := e.repository.ReserveIdempotencyKey(ctx, key)
+	if err != nil {
+		return nil, nil, err
+	}
+	if reserved {
+		return key, nil, nil
+	}
+
+	earlier, err := e.repository.FindIdempotencyKey(ctx, callerID, name)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// Released by a failed request in the meantime
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("request idempotency key was released: {%s}", name)
+		return nil, nil, status.Error(codes.Aborted, "Error request with this idempotency key failed, retry the request!")
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if earlier.RequestHash != key.RequestHash {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("request idempotency key was used for another comment: {%s}", name)
+		return nil, nil, status.Error(codes.InvalidArgument, "Error request idempotency key, was already used for a different comment!")
+	}
+	if earlier.CommentID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("request idempotency key is in progress: {%s}", name)
+		return nil, nil, status.Error(codes.Aborted, "Error request with this idempotency key is still in progress!")
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Infof("replayed comment: {%s}", earlier.CommentID)
+	return nil, &protobuffer.CreateCommentResponse{ID: earlier.CommentID, Status: models.StatusToProto(earlier.Status)}, nil
+}
+
+// Record the response of a reserved key, or release the key when the request failed so it can be retried
+func (e *Mod) completeIdempotencyKey(key *models.IdempotencyKey, response *protobuffer.CreateCommentResponse, err error) {
+	if key == nil {
+		return
+	}
+
+	// The key outlives the request, a caller that gave up must not leave it reserved
+	ctx := context.Background()
+
+	if err != nil {
+		if err := e.repository.ReleaseIdempotencyKey(ctx, key); err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("unable to release idempotency key: {%s} %v", key.Key, err)
+		}
+		return
+	}
+
+	key.CommentID = response.ID
+	key.Status = models.StatusFromProto(response.Status)
+	if err := e.repository.CompleteIdempotencyKey(ctx, key); err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_CreateComment"}).Errorf("unable to complete idempotency key: {%s} %v", key.Key, err)
+	}
+}
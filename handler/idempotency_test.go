@@ -0,0 +1,105 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func newIdempotencyContext(key string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(md_userID, "63b2dff9e834e550f0e50e66", md_idempotencyKey, key))
+}
+
+func newIdempotencyMock(key string, hash string, commentID string) (*gorm.DB, sqlmock.Sqlmock) {
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "idempotency_keys" ("caller_id","key","request_hash","comment_id","status","created_at","expires_at") VALUES ($1,$2,$3,$4,$5,$6,$7) ON CONFLICT ("caller_id","key") DO UPDATE SET "request_hash"="excluded"."request_hash","comment_id"="excluded"."comment_id","status"="excluded"."status","created_at"="excluded"."created_at","expires_at"="excluded"."expires_at" WHERE idempotency_keys.expires_at <= $8`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "idempotency_keys" WHERE caller_id = $1 AND key = $2 ORDER BY "idempotency_keys"."caller_id" LIMIT 1`)).
+		WithArgs("63b2dff9e834e550f0e50e66", key).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"CallerID", "Key", "RequestHash", "CommentID", "Status"}).
+			AddRow("63b2dff9e834e550f0e50e66", key, hash, commentID, models.StatusPending))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	return gdb, mock
+}
+
+// will test create comment retried with the same idempotency key
+func TestCreateCommentIdempotentRetry(t *testing.T) {
+	// Arrange
+	commentID := uuid.NewString()
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "Doesn't work!",
+	}
+	hash := requestHash(&models.Comment{ModID: request.ModID, UserID: request.UserID, Text: request.Text})
+	gdb, mock := newIdempotencyMock("retry-1", hash, commentID)
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	result, err := handler.CreateComment(newIdempotencyContext("retry-1"), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.ID, commentID)
+	assert.Equal(t, result.Status, protobuffer.CommentStatus_PENDING)
+}
+
+// will test create comment reusing an idempotency key for a different comment
+func TestCreateCommentIdempotencyKeyReused(t *testing.T) {
+	// Arrange
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "Doesn't work!",
+	}
+	hash := requestHash(&models.Comment{ModID: request.ModID, UserID: request.UserID, Text: "Great track"})
+	gdb, mock := newIdempotencyMock("retry-1", hash, uuid.NewString())
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err := handler.CreateComment(newIdempotencyContext("retry-1"), request)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test create comment with a too long idempotency key
+func TestCreateCommentIdempotencyKeyTooLong(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+	key := strings.Repeat("k", models.MaxIdempotencyKeyLength+1)
+
+	// Act
+	_, err := handler.CreateComment(newIdempotencyContext(key), &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "Doesn't work!",
+	})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
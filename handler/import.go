@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/archive"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+// Most errors listed in the response of an import, the others are only counted
+const maxImportErrors = 1000
+
+// Validates streamed comments by the rules of CreateComment and inserts them in batches, keeping their ids
+// and timestamps. Batches inserted before a failing one are kept.
+func (e *Mod) ImportComments(stream protobuffer.CommentService_ImportCommentsServer) error {
+	ctx := stream.Context()
+	caller := callerFromContext(ctx)
+	if caller.UserID == "" {
+		return status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if !caller.isAdmin() {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ImportComments"}).Errorf("caller {%s} is not allowed to import comments", caller.UserID)
+		return status.Error(codes.PermissionDenied, "Error caller is not allowed to import comments!")
+	}
+
+	reader := &importStream{stream: stream}
+	first, err := reader.peek()
+	if err != nil {
+		return err
+	}
+	conflict := archive.ConflictSkip
+	if first != nil && first.Conflict == protobuffer.ImportConflict_OVERWRITE {
+		conflict = archive.ConflictOverwrite
+	}
+	// Every batch is audited in its own transaction, batches before a failing one are kept
+	store := archive.NewAuditedStore(e.repository, models.AuditLog{
+		Action:    models.AuditActionImport,
+		Actor:     caller.UserID,
+		Detail:    fmt.Sprintf("conflict=%s", conflict),
+		RequestID: caller.RequestID,
+		ClientIP:  caller.ClientIP,
+	})
+	importer, err := archive.NewImporter(store, e.validate, archive.DefaultBatchSize, conflict)
+	if err != nil {
+		return err
+	}
+
+	report, importErr := importer.Import(ctx, reader)
+	detail := fmt.Sprintf("conflict=%s received=%d created=%d updated=%d skipped=%d rejected=%d", conflict, report.Read, report.Created, report.Updated, report.Skipped, len(report.Rejected))
+	if importErr != nil {
+		// The batches before the failing one are kept, audit what was imported before stopping
+		detail += fmt.Sprintf(" error=%q", importErr.Error())
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ImportComments"}).Errorf("import stopped after {%d} comments: %v", report.Created+report.Updated, importErr)
+	}
+
+	err = e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionImport, Detail: detail}, nil, nil)
+	if importErr != nil {
+		return importErr
+	}
+	if err != nil {
+		return err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ImportComments"}).Infof("comments imported: {%d/%d}", report.Created+report.Updated, report.Read)
+
+	return stream.SendAndClose(importResponse(report))
+}
+
+// Reads the comments of an import from its stream, a record per message
+type importStream struct {
+	stream protobuffer.CommentService_ImportCommentsServer
+	next   *protobuffer.ImportCommentsRequest
+	index  int
+}
+
+// Returns the first message without consuming it, nil for an empty stream
+func (s *importStream) peek() (*protobuffer.ImportCommentsRequest, error) {
+	req, err := s.stream.Recv()
+	if errors.Is(err, io.EOF) {
+		return nil, nil
+	}
+	s.next = req
+	return req, err
+}
+
+func (s *importStream) Read() (archive.Record, error) {
+	req := s.next
+	s.next = nil
+	if req == nil {
+		var err error
+		if req, err = s.stream.Recv(); err != nil {
+			return archive.Record{}, err
+		}
+	}
+	s.index++
+	if req.Comment == nil {
+		return archive.Record{}, &archive.RecordError{Line: s.index, Err: errors.New("missing Comment")}
+	}
+	return archive.RecordFromProto(req.Comment), nil
+}
+
+func (s *importStream) Line() int {
+	return s.index
+}
+
+func importResponse(report *archive.Report) *protobuffer.ImportCommentsResponse {
+	response := &protobuffer.ImportCommentsResponse{
+		Received: int64(report.Read),
+		Created:  int64(report.Created),
+		Updated:  int64(report.Updated),
+		Skipped:  int64(report.Skipped),
+		Rejected: int64(len(report.Rejected)),
+	}
+	for i, rejection := range report.Rejected {
+		if i == maxImportErrors {
+			break
+		}
+		response.Errors = append(response.Errors, &protobuffer.ImportError{Index: int64(rejection.Line), ID: rejection.ID, Error: rejection.Reason})
+	}
+	return response
+}
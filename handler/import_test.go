@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// Client side of an import, replaying requests and keeping the response
+type fakeImportStream struct {
+	grpc.ServerStream
+	ctx      context.Context
+	requests []*protobuffer.ImportCommentsRequest
+	response *protobuffer.ImportCommentsResponse
+}
+
+func (s *fakeImportStream) Context() context.Context {
+	return s.ctx
+}
+
+func (s *fakeImportStream) Recv() (*protobuffer.ImportCommentsRequest, error) {
+	if len(s.requests) == 0 {
+		return nil, io.EOF
+	}
+	req := s.requests[0]
+	s.requests = s.requests[1:]
+	return req, nil
+}
+
+func (s *fakeImportStream) SendAndClose(response *protobuffer.ImportCommentsResponse) error {
+	s.response = response
+	return nil
+}
+
+// will test import of a caller that is not an admin
+func TestImportCommentsNotAdmin(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+	stream := &fakeImportStream{ctx: NewCallerContext("moderator-1", "moderator")}
+
+	// Act
+	err := handler.ImportComments(stream)
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test import keeps ids and timestamps of valid comments and reports the others
+func TestImportComments(t *testing.T) {
+	// Arrange
+	id, modID := uuid.NewString(), uuid.NewString()
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE id IN ($1)`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(id))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","deleted_at","hidden","id","mod_id","spam_score","status","text","updated_at","user_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) ON CONFLICT ("id") DO UPDATE SET`)).
+		WithArgs(created, nil, false, id, modID, nil, "published", "Looks Nice", created, "63b2dff9e834e550f0e50e66").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntry(mock, "admin-1", "comment.import", id, "conflict=overwrite")
+	mock.ExpectCommit()
+	expectAudit(mock, "admin-1", "comment.import", "", "conflict=overwrite received=3 created=0 updated=1 skipped=0 rejected=2")
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+	stream := &fakeImportStream{ctx: NewCallerContext("admin-1", "admin"), requests: []*protobuffer.ImportCommentsRequest{
+		{Conflict: protobuffer.ImportConflict_OVERWRITE, Comment: &protobuffer.ImportedComment{ID: id, ModID: modID, UserID: "63b2dff9e834e550f0e50e66", Text: " Looks Nice ", Create_At: timestamppb.New(created)}},
+		{Comment: &protobuffer.ImportedComment{ModID: modID, UserID: "63b2dff9e834e550f0e50e66", Text: "Looks Nice"}},
+		{},
+	}}
+
+	// Act
+	err = handler.ImportComments(stream)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, int64(3), stream.response.Received)
+	assert.Equal(t, int64(1), stream.response.Updated)
+	assert.Equal(t, int64(2), stream.response.Rejected)
+	assert.Equal(t, []*protobuffer.ImportError{
+		{Index: 2, Error: "missing createdAt"},
+		{Index: 3, Error: "missing Comment"},
+	}, stream.response.Errors)
+}
+
+// will test an import that stops part way is audited with what it imported
+func TestImportCommentsStopped(t *testing.T) {
+	// Arrange
+	id, modID := uuid.NewString(), uuid.NewString()
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE id IN ($1)`)).
+		WithArgs(id).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "comments"`)).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+	expectAudit(mock, "admin-1", "comment.import", "", `conflict=skip received=1 created=0 updated=0 skipped=0 rejected=0 error="connection reset"`)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+	stream := &fakeImportStream{ctx: NewCallerContext("admin-1", "admin"), requests: []*protobuffer.ImportCommentsRequest{
+		{Comment: &protobuffer.ImportedComment{ID: id, ModID: modID, UserID: "63b2dff9e834e550f0e50e66", Text: "Looks Nice", Create_At: timestamppb.New(created)}},
+	}}
+
+	// Act
+	err = handler.ImportComments(stream)
+
+	// Assert
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Nil(t, stream.response)
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+)
+
+// Return the status of an error that ended with its context, other errors are returned as is
+func contextError(err error) error {
+	switch {
+	case errors.Is(err, context.DeadlineExceeded):
+		return status.Error(codes.DeadlineExceeded, "Error request took longer than its deadline!")
+	case errors.Is(err, context.Canceled):
+		return status.Error(codes.Canceled, "Error request was canceled!")
+	default:
+		return err
+	}
+}
+
+// Interceptor that reports queries ended by a deadline or cancellation with their gRPC code instead of Unknown
+func UnaryContextErrors() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		response, err := handler(ctx, req)
+		if err != nil {
+			err = contextError(err)
+		}
+		return response, err
+	}
+}
+
+// Interceptor that runs the queries of a request on behalf of its caller, so callers read their own writes
+func UnaryReadYourWrites() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if userID := callerFromContext(ctx).UserID; userID != "" {
+			ctx = repository.WithUser(ctx, userID)
+		}
+		return handler(ctx, req)
+	}
+}
+
+// Stream interceptor that reports queries ended by a deadline or cancellation with their gRPC code instead of Unknown
+func StreamContextErrors() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := handler(srv, stream); err != nil {
+			return contextError(err)
+		}
+		return nil
+	}
+}
+
+// Stream interceptor that runs the queries of a stream on behalf of its caller, so callers read their own writes
+func StreamReadYourWrites() grpc.StreamServerInterceptor {
+	return func(srv interface{}, stream grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if userID := callerFromContext(stream.Context()).UserID; userID != "" {
+			stream = &contextStream{ServerStream: stream, ctx: repository.WithUser(stream.Context(), userID)}
+		}
+		return handler(srv, stream)
+	}
+}
+
+// Server stream with a replaced context
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
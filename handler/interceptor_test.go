@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// will test queries ended by their context are reported with their gRPC code
+func TestUnaryContextErrors(t *testing.T) {
+	// Arrange
+	interceptor := UnaryContextErrors()
+	tests := map[error]codes.Code{
+		fmt.Errorf("timeout: %w", context.DeadlineExceeded): codes.DeadlineExceeded,
+		context.Canceled:                 codes.Canceled,
+		errors.New("connection refused"): codes.Unknown,
+	}
+
+	for err, code := range tests {
+		// Act
+		_, result := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, func(ctx context.Context, req interface{}) (interface{}, error) {
+			return nil, err
+		})
+
+		// Assert
+		assert.Equal(t, status.Code(result), code)
+	}
+}
+
+// will test streams ended by their context are reported with their gRPC code
+func TestStreamContextErrors(t *testing.T) {
+	// Arrange
+	interceptor := StreamContextErrors()
+	tests := map[error]codes.Code{
+		fmt.Errorf("timeout: %w", context.DeadlineExceeded): codes.DeadlineExceeded,
+		context.Canceled:                 codes.Canceled,
+		errors.New("connection refused"): codes.Unknown,
+	}
+
+	for err, code := range tests {
+		// Act
+		result := interceptor(nil, nil, &grpc.StreamServerInfo{}, func(srv interface{}, stream grpc.ServerStream) error {
+			return err
+		})
+
+		// Assert
+		assert.Equal(t, status.Code(result), code)
+	}
+}
@@ -0,0 +1,114 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/events"
+	"github.com/mxbikes/mxbikesclient.service.comment/mentions"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+)
+
+func (e *Mod) ListMentionsForUser(ctx context.Context, req *protobuffer.ListMentionsForUserRequest) (*protobuffer.ListMentionsForUserResponse, error) {
+	// Only the user itself or an admin may read the inbox
+	if err := e.authorizeUserData(callerFromContext(ctx), req.UserID, "SERVICE.Comment_ListMentionsForUser"); err != nil {
+		return nil, err
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	mentions, count, err := e.repository.SearchMentionsByUserID(ctx, req.UserID, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ListMentionsForUser"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.ListMentionsForUserResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Mentions:   models.MentionsToProto(mentions),
+	}, nil
+}
+
+// Store the mentions of a saved comment and notify every newly mentioned user.
+// Mentions are best effort, failures are logged and never fail the comment itself.
+func (e *Mod) syncMentions(ctx context.Context, comment *models.Comment, created bool) {
+	if e.directory == nil {
+		return
+	}
+
+	names := mentions.Parse(comment.Text)
+	if len(names) == 0 && created {
+		return
+	}
+
+	var userIDs []string
+	if len(names) > 0 {
+		resolved, err := e.directory.ResolveNames(ctx, names)
+		if err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Mentions"}).Errorf("unable to resolve mentions of comment {%s}: %v", comment.ID, err)
+			return
+		}
+		for _, name := range names {
+			if userID, ok := resolved[name]; ok && userID != comment.UserID {
+				userIDs = append(userIDs, userID)
+			}
+		}
+	}
+
+	// Users that blocked the author are never mentioned by them
+	blockers, err := e.repository.SearchBlockers(ctx, comment.UserID, userIDs)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Mentions"}).Errorf("unable to check blocks of comment {%s}: %v", comment.ID, err)
+		return
+	}
+	if len(blockers) > 0 {
+		blocked := make(map[string]bool, len(blockers))
+		for _, blocker := range blockers {
+			blocked[blocker] = true
+		}
+		allowed := userIDs[:0]
+		for _, userID := range userIDs {
+			if !blocked[userID] {
+				allowed = append(allowed, userID)
+			}
+		}
+		userIDs = allowed
+	}
+
+	added, err := e.repository.SyncMentions(ctx, comment, userIDs)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Mentions"}).Errorf("unable to store mentions of comment {%s}: %v", comment.ID, err)
+		return
+	}
+	if len(added) == 0 {
+		return
+	}
+
+	// Nobody else sees the comments of a shadow-banned author, so nobody is notified either
+	banned, err := e.repository.IsShadowBanned(ctx, comment.UserID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Mentions"}).Errorf("unable to check shadow-ban of user {%s}: %v", comment.UserID, err)
+		return
+	}
+	if banned {
+		return
+	}
+
+	for _, mention := range added {
+		err := e.publisher.Publish(ctx, events.Event{
+			Type:   events.TypeMention,
+			UserID: mention.UserID,
+			Attributes: map[string]string{
+				"commentId": mention.CommentID,
+				"modId":     mention.ModID,
+				"authorId":  mention.AuthorID,
+			},
+			OccurredAt: time.Now().UTC(),
+		})
+		if err != nil {
+			e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Mentions"}).Errorf("unable to publish mention of user {%s}: %v", mention.UserID, err)
+		}
+	}
+}
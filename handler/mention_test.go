@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/directory"
+	"github.com/mxbikes/mxbikesclient.service.comment/events"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type recordingPublisher struct {
+	events []events.Event
+}
+
+func (p *recordingPublisher) Publish(ctx context.Context, event events.Event) error {
+	p.events = append(p.events, event)
+	return nil
+}
+
+// will test create comment with mentions
+func TestCreateCommentWithMentions(t *testing.T) {
+	// Arrange
+	newId := uuid.New()
+	mentionedID := "63b2dff9e834e550f0e50e67"
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "@mx_fan try this, @Rider42 @nobody",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments"`)).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blocker_id" FROM "blocks" WHERE blocked_id = $1 AND blocker_id IN ($2)`)).
+		WithArgs(request.UserID, mentionedID).
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id"}))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "user_id" FROM "mentions" WHERE comment_id = $1`)).
+		WithArgs(newId.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "mentions" ("comment_id","mod_id","author_id","user_id","created_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(newId.String(), request.ModID, request.UserID, mentionedID, AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "shadow_bans" WHERE user_id = $1`)).
+		WithArgs(request.UserID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	publisher := &recordingPublisher{}
+	users := directory.NewStaticDirectory(map[string]string{"Rider42": request.UserID, "mx_fan": mentionedID})
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithUserDirectory(users), WithPublisher(publisher))
+
+	// Act
+	_, err = handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, len(publisher.events), 1)
+	assert.Equal(t, publisher.events[0].UserID, mentionedID)
+	assert.Equal(t, publisher.events[0].Type, events.TypeMention)
+}
+
+// will test list mentions of another user
+func TestListMentionsForUserPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.ListMentionsForUser(NewCallerContext("other", ""), &protobuffer.ListMentionsForUserRequest{UserID: "63b2dff9e834e550f0e50e66"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"context"
+	"errors"
+
+	"github.com/gogo/status"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"gorm.io/gorm"
+)
+
+func (e *Mod) HideComment(ctx context.Context, req *protobuffer.HideCommentRequest) (*protobuffer.HideCommentResponse, error) {
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_HideComment")
+	if err != nil {
+		return nil, err
+	}
+	before := *comment
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.SetHidden(ctx, comment, true); err != nil {
+			return err
+		}
+		comment.Hidden = true
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionHide, Target: req.ID}, &before, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_HideComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.HideCommentResponse{}, nil
+}
+
+func (e *Mod) UnhideComment(ctx context.Context, req *protobuffer.UnhideCommentRequest) (*protobuffer.UnhideCommentResponse, error) {
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_UnhideComment")
+	if err != nil {
+		return nil, err
+	}
+	before := *comment
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.SetHidden(ctx, comment, false); err != nil {
+			return err
+		}
+		comment.Hidden = false
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionUnhide, Target: req.ID}, &before, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UnhideComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.UnhideCommentResponse{}, nil
+}
+
+// Return the requested comment when the caller may moderate the comments of its mod, or is its author when allowed
+func (e *Mod) authorizedComment(ctx context.Context, caller caller, id string, allowAuthor bool, prefix string) (*models.Comment, error) {
+	if _, err := uuid.Parse(id); err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request ID is not a valid UUID: {%s}", id)
+		return nil, status.Error(codes.InvalidArgument, "Error request value ID, is not a valid UUID!")
+	}
+	if caller.UserID == "" {
+		return nil, status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+
+	comment, err := e.repository.FindByID(ctx, id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "Error comment not found!")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if allowAuthor && caller.UserID == comment.UserID {
+		return comment, nil
+	}
+	if !e.canModerate(ctx, caller, comment.ModID) {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("caller {%s} is not allowed to moderate mod {%s}", caller.UserID, comment.ModID)
+		return nil, status.Error(codes.PermissionDenied, "Error caller is not allowed to moderate this mod!")
+	}
+	return comment, nil
+}
+
+// Report whether the caller may moderate the comments of a mod, as moderator or as owner of the mod
+func (e *Mod) canModerate(ctx context.Context, caller caller, modID string) bool {
+	if caller.isModerator() {
+		return true
+	}
+	if e.ownership == nil || caller.UserID == "" {
+		return false
+	}
+
+	owner, err := e.ownership.OwnerOf(ctx, modID)
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Ownership"}).Errorf("unable to look up owner of mod {%s}: %v", modID, err)
+		return false
+	}
+	return owner != "" && owner == caller.UserID
+}
@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/ownership"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test hide comment by the owner of the mod
+func TestHideCommentByModOwner(t *testing.T) {
+	// Arrange
+	commentID, modID, ownerID := uuid.NewString(), uuid.NewString(), "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(commentID, modID, "63b2dff9e834e550f0e50e66", "Buy cheap bikes"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "hidden"=$1,"updated_at"=$2 WHERE id = $3 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(true, AnyTime{}, commentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`DELETE FROM "pins" WHERE comment_id = $1 RETURNING *`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}))
+	expectAuditEntry(mock, ownerID, models.AuditActionHide, commentID, "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	owners := ownership.NewStaticOwnership(map[string]string{modID: ownerID})
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithModOwnership(owners))
+
+	// Act
+	_, err = handler.HideComment(NewCallerContext(ownerID, ""), &protobuffer.HideCommentRequest{ID: commentID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/directory"
+	"github.com/mxbikes/mxbikesclient.service.comment/events"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/ownership"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
+)
+
+// Option configures optional behaviour of the handler
+type Option func(*Mod)
+
+// Use the given length limits for comment text instead of the defaults
+func WithTextLimits(limits models.TextLimits) Option {
+	return func(m *Mod) {
+		m.textLimits = limits
+	}
+}
+
+// Resolve @mentions through the given directory, mentions are ignored without one
+func WithUserDirectory(directory directory.UserDirectory) Option {
+	return func(m *Mod) {
+		m.directory = directory
+	}
+}
+
+// Publish events through the given publisher instead of the log
+func WithPublisher(publisher events.Publisher) Option {
+	return func(m *Mod) {
+		m.publisher = publisher
+	}
+}
+
+// Grant the owner of a mod moderation rights on its comments
+func WithModOwnership(ownership ownership.ModOwnership) Option {
+	return func(m *Mod) {
+		m.ownership = ownership
+	}
+}
+
+// Hold new comments for review, on every mod when global is set, and let authors
+// with at least trustedAfter approved comments skip the queue
+func WithPreModeration(global bool, trustedAfter int) Option {
+	return func(m *Mod) {
+		m.preModeration = global
+		m.trustedAfter = trustedAfter
+	}
+}
+
+// Score new comments for spam, the thresholds decide which are held for review or rejected
+func WithSpamScorer(scorer *spam.Scorer, thresholds spam.Thresholds) Option {
+	return func(m *Mod) {
+		m.spam = scorer
+		m.spamThresholds = thresholds
+	}
+}
+
+// Detect comments that repeat a comment of the same author on the same mod within window, near-duplicates
+// differ at most maxDistance fingerprint bits. Duplicates are rejected unless collapse is set,
+// then the earlier comment is returned instead. Detection is off while window is zero.
+func WithDuplicateDetection(window time.Duration, maxDistance int, collapse bool) Option {
+	return func(m *Mod) {
+		m.duplicateWindow = window
+		m.duplicateDistance = maxDistance
+		m.collapseDuplicates = collapse
+	}
+}
+
+// Remember idempotency keys of CreateComment for ttl, keys are ignored when ttl is zero
+func WithIdempotencyTTL(ttl time.Duration) Option {
+	return func(m *Mod) {
+		m.idempotencyTTL = ttl
+	}
+}
@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+func (e *Mod) PinComment(ctx context.Context, req *protobuffer.PinCommentRequest) (*protobuffer.PinCommentResponse, error) {
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_PinComment")
+	if err != nil {
+		return nil, err
+	}
+	if comment.Hidden {
+		return nil, status.Error(codes.FailedPrecondition, "Error hidden comments can not be pinned!")
+	}
+	if comment.Status == models.StatusPending || comment.Status == models.StatusRejected {
+		return nil, status.Error(codes.FailedPrecondition, "Error unpublished comments can not be pinned!")
+	}
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Pin(ctx, comment, int(req.Position), caller.UserID); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionPin, Target: req.ID, Detail: fmt.Sprintf("position=%d", req.Position)}, nil, nil)
+	})
+	if errors.Is(err, repository.ErrPinLimitReached) {
+		return nil, status.Errorf(codes.FailedPrecondition, "Error a mod can have at most %d pinned comments!", models.MaxPinsPerMod)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_PinComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.PinCommentResponse{}, nil
+}
+
+func (e *Mod) UnpinComment(ctx context.Context, req *protobuffer.UnpinCommentRequest) (*protobuffer.UnpinCommentResponse, error) {
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_UnpinComment")
+	if err != nil {
+		return nil, err
+	}
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.Unpin(ctx, comment); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionUnpin, Target: req.ID}, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_UnpinComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.UnpinCommentResponse{}, nil
+}
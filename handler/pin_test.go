@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test pin comment without authentication
+func TestPinCommentUnauthenticated(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.PinComment(context.Background(), &protobuffer.PinCommentRequest{ID: uuid.NewString()})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+// will test pin comment without moderation rights
+func TestPinCommentPermissionDenied(t *testing.T) {
+	// Arrange
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(commentID, uuid.NewString(), "63b2dff9e834e550f0e50e66", "Good Job!"))
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.PinComment(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.PinCommentRequest{ID: commentID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+const log_withUserID = "user with id: {%s} "
+
+func (e *Mod) ExportUserComments(ctx context.Context, req *protobuffer.ExportUserCommentsRequest) (*protobuffer.ExportUserCommentsResponse, error) {
+	// Only the user itself or an admin may export
+	caller := callerFromContext(ctx)
+	if err := e.authorizeUserData(caller, req.UserID, "SERVICE.Comment_ExportUserComments"); err != nil {
+		return nil, err
+	}
+
+	comments, err := e.repository.SearchAllByUserID(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	records, err := e.repository.SearchUserRecords(ctx, req.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(models.UserDataToExport(req.UserID, comments, records))
+	if err != nil {
+		return nil, status.Error(codes.Internal, "Error unable to encode user export!")
+	}
+
+	err = e.audit(ctx, caller, &models.AuditLog{
+		Action: models.AuditActionExportUser,
+		Target: req.UserID,
+		Detail: fmt.Sprintf("exported %d comments", len(comments)),
+	}, nil, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ExportUserComments"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.ExportUserCommentsResponse{Data: data, Count: int64(len(comments))}, nil
+}
+
+func (e *Mod) EraseUserData(ctx context.Context, req *protobuffer.EraseUserDataRequest) (*protobuffer.EraseUserDataResponse, error) {
+	// Only the user itself or an admin may erase
+	caller := callerFromContext(ctx)
+	if err := e.authorizeUserData(caller, req.UserID, "SERVICE.Comment_EraseUserData"); err != nil {
+		return nil, err
+	}
+
+	if req.Mode != protobuffer.EraseMode_HARD_DELETE && req.Mode != protobuffer.EraseMode_ANONYMISE {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_EraseUserData"}).Errorf("request Mode is not valid: {%s}", req.Mode)
+		return nil, status.Error(codes.InvalidArgument, "Error request value Mode, must be HARD_DELETE or ANONYMISE!")
+	}
+	if req.BlankText && req.Mode != protobuffer.EraseMode_ANONYMISE {
+		return nil, status.Error(codes.InvalidArgument, "Error request value BlankText, is only allowed with ANONYMISE!")
+	}
+
+	// Every batch commits on its own, the batches erased before a failing one are audited with its error
+	affected, eraseErr := e.repository.EraseByUserID(ctx, req.UserID, req.Mode == protobuffer.EraseMode_ANONYMISE, req.BlankText)
+	detail := fmt.Sprintf("mode=%s blank_text=%t affected=%d", req.Mode, req.BlankText, affected)
+	if eraseErr != nil {
+		detail += fmt.Sprintf(" error=%q", eraseErr.Error())
+	}
+	err := e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionEraseUser, Target: req.UserID, Detail: detail}, nil, nil)
+	if eraseErr != nil {
+		return nil, eraseErr
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_EraseUserData"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.EraseUserDataResponse{Affected: affected}, nil
+}
+
+func (e *Mod) authorizeUserData(caller caller, userID string, prefix string) error {
+	if userID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Error("request UserID is empty")
+		return status.Error(codes.InvalidArgument, "Error request value UserID, is required!")
+	}
+	if caller.UserID == "" {
+		return status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if caller.UserID != userID && !caller.isAdmin() {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("caller {%s} is not allowed to access data of user {%s}", caller.UserID, userID)
+		return status.Error(codes.PermissionDenied, "Error caller is not allowed to access this user's data!")
+	}
+	return nil
+}
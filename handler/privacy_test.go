@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+func NewCallerContext(userID string, roles string) context.Context {
+	return metadata.NewIncomingContext(context.Background(), metadata.Pairs(md_userID, userID, md_roles, roles))
+}
+
+// will test export user comments without authentication
+func TestExportUserCommentsUnauthenticated(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.ExportUserComments(context.Background(), &protobuffer.ExportUserCommentsRequest{UserID: "63b2dff9e834e550f0e50e66"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.Unauthenticated)
+}
+
+// will test export user comments of another user
+func TestExportUserCommentsPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.ExportUserComments(NewCallerContext("other", ""), &protobuffer.ExportUserCommentsRequest{UserID: "63b2dff9e834e550f0e50e66"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test export user comments
+func TestExportUserComments(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE user_id = $1 ORDER BY "comments"."id" LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(uuid.New().String(), uuid.New().String(), userID, "Good Job!"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "mentions" WHERE author_id = $1 OR user_id = $2 ORDER BY created_at`)).
+		WithArgs(userID, userID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "author_id", "user_id"}).AddRow(uuid.NewString(), "63b2dff9e834e550f0e50e67", userID))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blocks" WHERE blocker_id = $1 ORDER BY created_at`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id", "blocked_id"}).AddRow(userID, "63b2dff9e834e550f0e50e68"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "shadow_bans" WHERE user_id = $1 LIMIT 1`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id", "banned_by", "reason"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "reviews" WHERE user_id = $1 ORDER BY created_at`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "user_id", "status"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "idempotency_keys" WHERE caller_id = $1 ORDER BY created_at`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"caller_id", "key", "comment_id"}).AddRow(userID, "retry-1", uuid.NewString()))
+	expectAudit(mock, "admin-1", models.AuditActionExportUser, userID, "exported 1 comments")
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	repo := repository.NewRepository(gdb)
+	handler := New(repo, logrus.New())
+
+	// Act
+	result, err := handler.ExportUserComments(NewCallerContext("admin-1", "admin"), &protobuffer.ExportUserCommentsRequest{UserID: userID})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, result.Count, int64(1))
+
+	var export models.UserExport
+	assert.NoError(t, json.Unmarshal(result.Data, &export))
+	assert.Equal(t, export.Comments[0].Text, "Good Job!")
+	assert.Equal(t, export.Mentions[0].AuthorID, "63b2dff9e834e550f0e50e67")
+	assert.Equal(t, export.Blocks[0].BlockedID, "63b2dff9e834e550f0e50e68")
+	assert.Nil(t, export.ShadowBan)
+	assert.Equal(t, export.IdempotencyKeys[0].Key, "retry-1")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test erase user data without mode
+func TestEraseUserDataModeUnspecified(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.EraseUserData(NewCallerContext(userID, ""), &protobuffer.EraseUserDataRequest{UserID: userID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test erase user data blanking text on hard delete
+func TestEraseUserDataBlankTextWithHardDelete(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.EraseUserData(NewCallerContext(userID, ""), &protobuffer.EraseUserDataRequest{UserID: userID, Mode: protobuffer.EraseMode_HARD_DELETE, BlankText: true})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.InvalidArgument)
+}
+
+// will test an erase that stops part way is audited with the batches it committed
+func TestEraseUserDataStopped(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(commentID))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "comments" WHERE id IN ($1)`)).
+		WithArgs(commentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnError(errors.New("connection reset"))
+	expectAudit(mock, userID, models.AuditActionEraseUser, userID, `mode=HARD_DELETE blank_text=false affected=1 error="connection reset"`)
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.EraseUserData(NewCallerContext(userID, ""), &protobuffer.EraseUserDataRequest{UserID: userID, Mode: protobuffer.EraseMode_HARD_DELETE})
+
+	// Assert
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,171 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/gogo/status"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+)
+
+func (e *Mod) SetModerationMode(ctx context.Context, req *protobuffer.SetModerationModeRequest) (*protobuffer.SetModerationModeResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := e.authorizeMod(ctx, caller, req.ModID, "SERVICE.Comment_SetModerationMode"); err != nil {
+		return nil, err
+	}
+
+	setting := &models.ModerationSetting{ModID: req.ModID, PreModeration: req.PreModeration, UpdatedBy: caller.UserID}
+	err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.SetPreModerated(ctx, setting); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionModerationMode, Target: req.ModID, Detail: fmt.Sprintf("pre_moderation=%t", req.PreModeration)}, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_SetModerationMode"}).Infof(log_withID, req.ModID)
+
+	return &protobuffer.SetModerationModeResponse{}, nil
+}
+
+func (e *Mod) ListPendingComments(ctx context.Context, req *protobuffer.ListPendingCommentsRequest) (*protobuffer.ListPendingCommentsResponse, error) {
+	// The queue of every mod is for moderators, the queue of one mod also for its owner
+	caller := callerFromContext(ctx)
+	if req.ModID == "" {
+		if err := e.authorizeModerator(caller, "SERVICE.Comment_ListPendingComments"); err != nil {
+			return nil, err
+		}
+	} else if err := e.authorizeMod(ctx, caller, req.ModID, "SERVICE.Comment_ListPendingComments"); err != nil {
+		return nil, err
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	comments, count, err := e.repository.SearchPending(ctx, req.ModID, pagination, repository.Viewer{Moderator: caller.isModerator()})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ListPendingComments"}).Infof("pending count: {%d} ", count)
+
+	return &protobuffer.ListPendingCommentsResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Comments:   models.CommentsToProto(comments),
+	}, nil
+}
+
+func (e *Mod) ApproveComment(ctx context.Context, req *protobuffer.ApproveCommentRequest) (*protobuffer.ApproveCommentResponse, error) {
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_ApproveComment")
+	if err != nil {
+		return nil, err
+	}
+
+	before := *comment
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.review(ctx, comment, models.StatusPublished, caller.UserID, ""); err != nil {
+			return err
+		}
+		comment.Status = models.StatusPublished
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionApprove, Target: req.ID}, &before, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Mentions are only sent once the comment is visible
+	e.syncMentions(ctx, comment, true)
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ApproveComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.ApproveCommentResponse{}, nil
+}
+
+func (e *Mod) RejectComment(ctx context.Context, req *protobuffer.RejectCommentRequest) (*protobuffer.RejectCommentResponse, error) {
+	if utf8.RuneCountInString(req.Reason) > models.MaxReviewReasonLength {
+		return nil, status.Errorf(codes.InvalidArgument, "Error request value Reason, must be at most %d characters!", models.MaxReviewReasonLength)
+	}
+
+	caller := callerFromContext(ctx)
+	comment, err := e.authorizedComment(ctx, caller, req.ID, false, "SERVICE.Comment_RejectComment")
+	if err != nil {
+		return nil, err
+	}
+
+	before := *comment
+
+	err = e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.review(ctx, comment, models.StatusRejected, caller.UserID, req.Reason); err != nil {
+			return err
+		}
+		comment.Status = models.StatusRejected
+		return e.audit(ctx, caller, &models.AuditLog{Action: models.AuditActionReject, Target: req.ID, Detail: fmt.Sprintf("reason=%q", req.Reason)}, &before, comment)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_RejectComment"}).Infof(log_withID, req.ID)
+
+	return &protobuffer.RejectCommentResponse{}, nil
+}
+
+func (e *Mod) review(ctx context.Context, comment *models.Comment, decision string, reviewedBy string, reason string) error {
+	err := e.repository.Review(ctx, &models.Review{
+		CommentID:  comment.ID,
+		ModID:      comment.ModID,
+		UserID:     comment.UserID,
+		Status:     decision,
+		ReviewedBy: reviewedBy,
+		Reason:     reason,
+	})
+	if errors.Is(err, repository.ErrNotPending) {
+		return status.Error(codes.FailedPrecondition, "Error comment is not pending review!")
+	}
+	return err
+}
+
+// Report whether a new comment has to wait for review, authors with enough approved comments skip the queue
+func (e *Mod) requiresReview(ctx context.Context, comment *models.Comment) (bool, error) {
+	moderated := e.preModeration
+	if !moderated {
+		var err error
+		if moderated, err = e.repository.IsPreModerated(ctx, comment.ModID); err != nil {
+			return false, err
+		}
+	}
+	if !moderated || e.trustedAfter <= 0 {
+		return moderated, nil
+	}
+
+	approved, err := e.repository.CountApproved(ctx, comment.UserID)
+	if err != nil {
+		return false, err
+	}
+	return approved < int64(e.trustedAfter), nil
+}
+
+// Only moderators and the owner of a mod manage its settings and queue
+func (e *Mod) authorizeMod(ctx context.Context, caller caller, modID string, prefix string) error {
+	if _, err := uuid.Parse(modID); err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("request ModID is not a valid UUID: {%s}", modID)
+		return status.Error(codes.InvalidArgument, "Error request value ModID, is not a valid UUID!")
+	}
+	if caller.UserID == "" {
+		return status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if !e.canModerate(ctx, caller, modID) {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("caller {%s} is not allowed to moderate mod {%s}", caller.UserID, modID)
+		return status.Error(codes.PermissionDenied, "Error caller is not allowed to moderate this mod!")
+	}
+	return nil
+}
@@ -0,0 +1,141 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test create comment is held for review under global pre-moderation
+func TestCreateCommentPending(t *testing.T) {
+	// Arrange
+	newId := uuid.New()
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "comment 2",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reviews" WHERE user_id = $1 AND status = $2`)).
+		WithArgs(request.UserID, "published").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(2))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","status","user_id","text") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id","user_id","text"`)).
+		WithArgs(AnyTime{}, AnyTime{}, nil, request.ModID, "pending", request.UserID, request.Text).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithPreModeration(true, 3))
+
+	// Act
+	result, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Status, protobuffer.CommentStatus_PENDING)
+}
+
+// will test create comment of a trusted author skips the review queue
+func TestCreateCommentTrusted(t *testing.T) {
+	// Arrange
+	newId := uuid.New()
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "comment 2",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "moderation_settings" WHERE mod_id = $1 LIMIT 1`)).
+		WithArgs(request.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"mod_id", "pre_moderation"}).AddRow(request.ModID, true))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "reviews" WHERE user_id = $1 AND status = $2`)).
+		WithArgs(request.UserID, "published").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(3))
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id","user_id","text"`)).
+		WithArgs(AnyTime{}, AnyTime{}, nil, request.ModID, request.UserID, request.Text).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithPreModeration(false, 3))
+
+	// Act
+	result, err := handler.CreateComment(context.Background(), request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Status, protobuffer.CommentStatus_PUBLISHED)
+}
+
+// will test approve comment that is not pending
+func TestApproveCommentNotPending(t *testing.T) {
+	// Arrange
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "Status"}).
+			AddRow(commentID, uuid.NewString(), "63b2dff9e834e550f0e50e66", "Good Job!", "published"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "status"=$1,"updated_at"=$2 WHERE (id = $3 AND status = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs("published", AnyTime{}, commentID, "pending").
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectRollback()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.ApproveComment(NewCallerContext("moderator-1", "moderator"), &protobuffer.ApproveCommentRequest{ID: commentID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.FailedPrecondition)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test list the pending comments of every mod without moderator role
+func TestListPendingCommentsPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.ListPendingComments(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.ListPendingCommentsRequest{})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
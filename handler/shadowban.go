@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/gogo/status"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/codes"
+	"gorm.io/gorm"
+)
+
+func (e *Mod) ShadowBanUser(ctx context.Context, req *protobuffer.ShadowBanUserRequest) (*protobuffer.ShadowBanUserResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := e.authorizeModerator(caller, "SERVICE.Comment_ShadowBanUser"); err != nil {
+		return nil, err
+	}
+	if req.UserID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ShadowBanUser"}).Error("request UserID is empty")
+		return nil, status.Error(codes.InvalidArgument, "Error request value UserID, is required!")
+	}
+	if req.UserID == caller.UserID {
+		return nil, status.Error(codes.InvalidArgument, "Error request value UserID, can not be the caller!")
+	}
+	if utf8.RuneCountInString(req.Reason) > models.MaxShadowBanReasonLength {
+		return nil, status.Errorf(codes.InvalidArgument, "Error request value Reason, must be at most %d characters!", models.MaxShadowBanReasonLength)
+	}
+
+	err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.ShadowBan(ctx, &models.ShadowBan{UserID: req.UserID, BannedBy: caller.UserID, Reason: req.Reason}); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{
+			Action: models.AuditActionShadowBan,
+			Target: req.UserID,
+			Detail: fmt.Sprintf("reason=%q", req.Reason),
+		}, nil, nil)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ShadowBanUser"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.ShadowBanUserResponse{}, nil
+}
+
+func (e *Mod) LiftShadowBan(ctx context.Context, req *protobuffer.LiftShadowBanRequest) (*protobuffer.LiftShadowBanResponse, error) {
+	caller := callerFromContext(ctx)
+	if err := e.authorizeModerator(caller, "SERVICE.Comment_LiftShadowBan"); err != nil {
+		return nil, err
+	}
+	if req.UserID == "" {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_LiftShadowBan"}).Error("request UserID is empty")
+		return nil, status.Error(codes.InvalidArgument, "Error request value UserID, is required!")
+	}
+
+	err := e.repository.Transaction(ctx, func(ctx context.Context) error {
+		if err := e.repository.LiftShadowBan(ctx, req.UserID); err != nil {
+			return err
+		}
+		return e.audit(ctx, caller, &models.AuditLog{
+			Action: models.AuditActionShadowLift,
+			Target: req.UserID,
+		}, nil, nil)
+	})
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, status.Error(codes.NotFound, "Error user is not shadow-banned!")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_LiftShadowBan"}).Infof(log_withUserID, req.UserID)
+
+	return &protobuffer.LiftShadowBanResponse{}, nil
+}
+
+func (e *Mod) ListShadowBans(ctx context.Context, req *protobuffer.ListShadowBansRequest) (*protobuffer.ListShadowBansResponse, error) {
+	if err := e.authorizeModerator(callerFromContext(ctx), "SERVICE.Comment_ListShadowBans"); err != nil {
+		return nil, err
+	}
+	pagination := models.NewPagination(req.Page, req.Size)
+
+	bans, count, err := e.repository.SearchShadowBans(ctx, pagination)
+	if err != nil {
+		return nil, err
+	}
+
+	e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_ListShadowBans"}).Infof("shadow-bans count: {%d} ", count)
+
+	return &protobuffer.ListShadowBansResponse{
+		Pagination: models.PaginationToProto(pagination, count),
+		Bans:       models.ShadowBansToProto(bans),
+	}, nil
+}
+
+// Only site moderators manage site wide lists, mod owners do not
+func (e *Mod) authorizeModerator(caller caller, prefix string) error {
+	if caller.UserID == "" {
+		return status.Error(codes.Unauthenticated, "Error request is not authenticated!")
+	}
+	if !caller.isModerator() {
+		e.logger.WithFields(logrus.Fields{"prefix": prefix}).Errorf("caller {%s} is not a moderator", caller.UserID)
+		return status.Error(codes.PermissionDenied, "Error caller is not a moderator!")
+	}
+	return nil
+}
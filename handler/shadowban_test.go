@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"log"
+	"regexp"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// will test shadow-ban user without moderator role
+func TestShadowBanUserPermissionDenied(t *testing.T) {
+	// Arrange
+	handler := NewDefaultHandler()
+
+	// Act
+	_, err := handler.ShadowBanUser(NewCallerContext("63b2dff9e834e550f0e50e66", ""), &protobuffer.ShadowBanUserRequest{UserID: "63b2dff9e834e550f0e50e67"})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.PermissionDenied)
+}
+
+// will test shadow-ban user is stored and audited
+func TestShadowBanUser(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "shadow_bans" ("user_id","banned_by","reason","created_at") VALUES ($1,$2,$3,$4) ON CONFLICT ("user_id") DO UPDATE SET "banned_by"="excluded"."banned_by","reason"="excluded"."reason"`)).
+		WithArgs(userID, "moderator-1", "spam", AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	expectAuditEntry(mock, "moderator-1", models.AuditActionShadowBan, userID, `reason="spam"`)
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.ShadowBanUser(NewCallerContext("moderator-1", "moderator"), &protobuffer.ShadowBanUserRequest{UserID: userID, Reason: "spam"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test lift shadow-ban of a user that is not banned
+func TestLiftShadowBanNotFound(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "shadow_bans" WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	handler := New(repository.NewRepository(gdb), logrus.New())
+
+	// Act
+	_, err = handler.LiftShadowBan(NewCallerContext("moderator-1", "moderator"), &protobuffer.LiftShadowBanRequest{UserID: userID})
+
+	// Assert
+	assert.Equal(t, status.Code(err), codes.NotFound)
+}
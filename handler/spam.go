@@ -0,0 +1,58 @@
+package handler
+
+import (
+	"context"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
+	"github.com/sirupsen/logrus"
+	"google.golang.org/grpc/metadata"
+)
+
+// Score a new comment for spam and return the status it is stored with, empty when published.
+// Scoring is best effort, a failure is logged and the comment goes through unscored.
+func (e *Mod) spamStatus(ctx context.Context, comment *models.Comment) string {
+	if e.spam == nil {
+		return ""
+	}
+
+	result, err := e.spam.Score(ctx, spam.Input{
+		UserID:         comment.UserID,
+		ModID:          comment.ModID,
+		Text:           comment.Text,
+		AccountCreated: accountCreatedFromContext(ctx),
+	})
+	if err != nil {
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Spam"}).Errorf("unable to score comment of user {%s}: %v", comment.UserID, err)
+		return ""
+	}
+	comment.SpamScore = &result.Score
+
+	switch e.spamThresholds.Verdict(result.Score) {
+	case spam.Reject:
+		e.logger.WithFields(logrus.Fields{"prefix": "SERVICE.Comment_Spam"}).Infof("rejected comment of user {%s} with score {%.2f}", comment.UserID, result.Score)
+		return models.StatusRejected
+	case spam.Review:
+		return models.StatusPending
+	default:
+		return ""
+	}
+}
+
+// Return the creation time of the caller's account set by the gateway, zero when unknown
+func accountCreatedFromContext(ctx context.Context) time.Time {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return time.Time{}
+	}
+	values := md.Get(md_accountCreated)
+	if len(values) == 0 {
+		return time.Time{}
+	}
+	created, err := time.Parse(time.RFC3339, values[0])
+	if err != nil {
+		return time.Time{}
+	}
+	return created
+}
@@ -0,0 +1,70 @@
+package handler
+
+import (
+	"context"
+	"log"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/google/uuid"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/repository"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc/metadata"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+type staticHistory struct {
+	recent     int64
+	duplicates int64
+}
+
+func (h staticHistory) CountRecentByUserID(ctx context.Context, userID string, since time.Time) (int64, error) {
+	return h.recent, nil
+}
+
+func (h staticHistory) CountDuplicates(ctx context.Context, userID string, modID string, text string, since time.Time) (int64, error) {
+	return h.duplicates, nil
+}
+
+// will test create comment rejected as spam
+func TestCreateCommentSpamRejected(t *testing.T) {
+	// Arrange
+	newId := uuid.New()
+	request := &protobuffer.CreateCommentRequest{
+		ModID:  uuid.NewString(),
+		UserID: "63b2dff9e834e550f0e50e66",
+		Text:   "cheap mods https://cheap-mods.example",
+	}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","status","spam_score","user_id","text") VALUES ($1,$2,$3,$4,$5,$6,$7,$8) RETURNING "id","user_id","text"`)).
+		WithArgs(AnyTime{}, AnyTime{}, nil, request.ModID, "rejected", sqlmock.AnyArg(), request.UserID, request.Text).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
+	expectAuditEntry(mock, "", models.AuditActionCreate, newId.String(), "")
+	mock.ExpectCommit()
+
+	gdb, err := gorm.Open(postgres.New(postgres.Config{Conn: db}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf(log_failedConn, err)
+	}
+
+	scorer := spam.NewScorer(staticHistory{recent: 10, duplicates: 4}, nil)
+	handler := New(repository.NewRepository(gdb), logrus.New(), WithSpamScorer(scorer, spam.DefaultThresholds))
+	ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(md_accountCreated, time.Now().Add(-time.Hour).Format(time.RFC3339)))
+
+	// Act
+	result, err := handler.CreateComment(ctx, request)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Status, protobuffer.CommentStatus_REJECTED)
+}
@@ -1,27 +1,65 @@
 package main
 
 import (
+	"context"
 	"log"
 	"net"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
+	"github.com/mxbikes/mxbikesclient.service.comment/directory"
+	"github.com/mxbikes/mxbikesclient.service.comment/duplicates"
 	"github.com/mxbikes/mxbikesclient.service.comment/handler"
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"github.com/mxbikes/mxbikesclient.service.comment/ownership"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	modbuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/mod"
 	"github.com/mxbikes/mxbikesclient.service.comment/repository"
-	protobuffer "github.com/mxbikes/protobuf/comment"
+	"github.com/mxbikes/mxbikesclient.service.comment/spam"
 	"github.com/sirupsen/logrus"
 	prefixed "github.com/x-cray/logrus-prefixed-formatter"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
 	"google.golang.org/grpc/reflection"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
 )
 
 var (
-	logLevel    = getEnv("LOG_LEVEL")
-	port        = getEnv("PORT")
-	postgresUrl = getEnv("POSTGRES_URI")
-	serviceName = getEnv("SERVICE_NAME")
+	logLevel       = getEnv("LOG_LEVEL")
+	port           = getEnv("PORT")
+	postgresUrl    = getEnv("POSTGRES_URI")
+	queryTimeout   = getEnvDuration("QUERY_TIMEOUT", 10*time.Second)
+	replicaUrls    = getEnv("POSTGRES_REPLICA_URIS")
+	replicaCheck   = getEnvDuration("REPLICA_CHECK_INTERVAL", 10*time.Second)
+	readOwnWrites  = getEnvDuration("READ_YOUR_WRITES_WINDOW", 5*time.Second)
+	cacheSize      = getEnvInt("CACHE_SIZE", 1000)
+	cacheTTL       = getEnvDuration("CACHE_TTL", 30*time.Second)
+	cacheStats     = getEnvDuration("CACHE_STATS_INTERVAL", time.Minute)
+	serviceName    = getEnv("SERVICE_NAME")
+	usersFile      = getEnv("USER_DIRECTORY_FILE")
+	modService     = getEnv("MOD_SERVICE_ADDR")
+	ownersFile     = getEnv("MOD_OWNERSHIP_FILE")
+	ownersTTL      = getEnvDuration("MOD_OWNERSHIP_TTL", 5*time.Minute)
+	preModerate    = getEnvBool("PREMODERATION", false)
+	trustAfter     = getEnvInt("PREMODERATION_TRUSTED_AFTER", 5)
+	spamScoring    = getEnvBool("SPAM_SCORING", false)
+	spamModelFile  = getEnv("SPAM_MODEL_FILE")
+	spamThresholds = spam.Thresholds{
+		Review: getEnvFloat("SPAM_REVIEW_THRESHOLD", spam.DefaultThresholds.Review),
+		Reject: getEnvFloat("SPAM_REJECT_THRESHOLD", spam.DefaultThresholds.Reject),
+	}
+	duplicateWindow   = getEnvDuration("DUPLICATE_WINDOW", 0)
+	duplicateDistance = getEnvInt("DUPLICATE_DISTANCE", duplicates.DefaultMaxDistance)
+	duplicateMode     = getEnv("DUPLICATE_MODE")
+	idempotencyTTL    = getEnvDuration("IDEMPOTENCY_TTL", 24*time.Hour)
+	textLimits        = models.TextLimits{
+		Min: getEnvInt("COMMENT_MIN_LENGTH", models.DefaultMinTextLength),
+		Max: getEnvInt("COMMENT_MAX_LENGTH", models.DefaultMaxTextLength),
+	}
 )
 
 func main() {
@@ -35,14 +73,113 @@ func main() {
 		},
 	}
 
+	if textLimits.Min < 1 || textLimits.Max < textLimits.Min {
+		logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("invalid comment length limits: {%d-%d}", textLimits.Min, textLimits.Max)
+	}
+
 	/* Database */
 	db, err := gorm.Open(postgres.Open(postgresUrl), &gorm.Config{})
 	if err != nil {
 		logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Fatal("unable to open a connection to database")
 	}
 	logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Info("connection has been established successfully!")
-	repo := repository.NewRepository(db)
-	repo.Migrate()
+	repositoryOptions := []repository.Option{
+		repository.WithQueryTimeout(queryTimeout),
+		repository.WithReadYourWrites(readOwnWrites),
+	}
+	if replicaUrls != "" {
+		var replicas []*gorm.DB
+		for _, url := range strings.Split(replicaUrls, ",") {
+			// Unreachable replicas are left to the health check instead of failing startup
+			replica, err := gorm.Open(postgres.Open(strings.TrimSpace(url)), &gorm.Config{DisableAutomaticPing: true})
+			if err != nil {
+				logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Fatal("unable to open a connection to replica")
+			}
+			replicas = append(replicas, replica)
+		}
+		repositoryOptions = append(repositoryOptions, repository.WithReplicas(replicas...))
+	}
+	repo := repository.NewRepository(db, repositoryOptions...)
+	if err := repo.Migrate(context.Background()); err != nil {
+		logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Fatalf("unable to migrate the database: %v", err)
+	}
+
+	/* Commands */
+	if len(os.Args) > 1 {
+		if err := runCommand(context.Background(), repo, os.Args[1:], os.Stdout); err != nil {
+			logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatal(err)
+		}
+		return
+	}
+
+	if duplicateMode != "" && duplicateMode != "reject" && duplicateMode != "collapse" {
+		logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("invalid duplicate mode: {%s}", duplicateMode)
+	}
+
+	options := []handler.Option{
+		handler.WithTextLimits(textLimits),
+		handler.WithPreModeration(preModerate, trustAfter),
+		handler.WithDuplicateDetection(duplicateWindow, duplicateDistance, duplicateMode == "collapse"),
+		handler.WithIdempotencyTTL(idempotencyTTL),
+	}
+	if usersFile != "" {
+		users, err := directory.NewFileDirectory(usersFile)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("unable to load user directory: %v", err)
+		}
+		options = append(options, handler.WithUserDirectory(users))
+	}
+
+	switch {
+	case ownersFile != "":
+		owners, err := ownership.NewFileOwnership(ownersFile)
+		if err != nil {
+			logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("unable to load mod ownership: %v", err)
+		}
+		options = append(options, handler.WithModOwnership(owners))
+	case modService != "":
+		conn, err := grpc.Dial(modService, grpc.WithTransportCredentials(insecure.NewCredentials()))
+		if err != nil {
+			logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("unable to connect to mod service: %v", err)
+		}
+		defer conn.Close()
+		owners := ownership.NewGrpcOwnership(modbuffer.NewModServiceClient(conn))
+		options = append(options, handler.WithModOwnership(ownership.NewCache(owners, ownersTTL)))
+	}
+
+	if spamScoring {
+		var model *spam.Model
+		if spamModelFile != "" {
+			model, err = spam.LoadModel(spamModelFile)
+			if err != nil {
+				logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("unable to load spam model: %v", err)
+			}
+		}
+		if spamThresholds.Review > spamThresholds.Reject {
+			logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("invalid spam thresholds: {%.2f-%.2f}", spamThresholds.Review, spamThresholds.Reject)
+		}
+		options = append(options, handler.WithSpamScorer(spam.NewScorer(repo, model), spamThresholds))
+	}
+
+	/* Replicas */
+	repo.MonitorReplicas(context.Background(), replicaCheck, func(healthy int, total int) {
+		logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Infof("healthy replicas: {%d/%d}", healthy, total)
+	})
+
+	/* Idempotency keys */
+	if idempotencyTTL > 0 {
+		go purgeIdempotencyKeys(repo, logger, time.Hour)
+	}
+
+	/* Cache */
+	var modRepository repository.ModRepository = repo
+	if cacheSize > 0 {
+		cached := repository.NewCachedRepository(repo, repository.NewLRUCache(cacheSize, cacheTTL))
+		if cacheStats > 0 {
+			go reportCacheStats(cached, logger, cacheStats)
+		}
+		modRepository = cached
+	}
 
 	/* Server */
 	// Create a tcp listener
@@ -51,9 +188,12 @@ func main() {
 		logger.WithFields(logrus.Fields{"prefix": serviceName}).Fatalf("failed to listen: %v", err)
 	}
 
-	grpcServer := grpc.NewServer()
+	grpcServer := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(handler.UnaryContextErrors(), handler.UnaryReadYourWrites()),
+		grpc.ChainStreamInterceptor(handler.StreamContextErrors(), handler.StreamReadYourWrites()),
+	)
 
-	protobuffer.RegisterCommentServiceServer(grpcServer, handler.New(repo, *logger))
+	protobuffer.RegisterCommentServiceServer(grpcServer, handler.New(modRepository, logger, options...))
 	reflection.Register(grpcServer)
 
 	// Start grpc server on listener
@@ -63,6 +203,26 @@ func main() {
 	}
 }
 
+// Delete expired idempotency keys every interval
+func purgeIdempotencyKeys(repo repository.ModRepository, logger *logrus.Logger, interval time.Duration) {
+	for range time.Tick(interval) {
+		purged, err := repo.PurgeIdempotencyKeys(context.Background(), time.Now())
+		if err != nil {
+			logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Errorf("unable to purge idempotency keys: %v", err)
+			continue
+		}
+		logger.WithFields(logrus.Fields{"prefix": "POSTGRES"}).Debugf("purged idempotency keys: {%d}", purged)
+	}
+}
+
+// Log the hit rate of the comment cache every interval
+func reportCacheStats(cache interface{ Stats() repository.CacheStats }, logger *logrus.Logger, interval time.Duration) {
+	for range time.Tick(interval) {
+		stats := cache.Stats()
+		logger.WithFields(logrus.Fields{"prefix": "CACHE"}).Infof("hit rate: {%.2f} hits: {%d} misses: {%d} coalesced: {%d}", stats.HitRate(), stats.Hits, stats.Misses, stats.Coalesced)
+	}
+}
+
 func GetEnv(key, fallback string) string {
 	if value, ok := os.LookupEnv(key); ok {
 		return value
@@ -78,3 +238,55 @@ func getEnv(key string) string {
 
 	return os.Getenv(key)
 }
+
+func getEnvInt(key string, fallback int) int {
+	value := getEnv(key)
+	if value == "" {
+		return fallback
+	}
+
+	result, err := strconv.Atoi(value)
+	if err != nil {
+		log.Fatalf("Error environment variable %s is not a number", key)
+	}
+	return result
+}
+
+func getEnvDuration(key string, fallback time.Duration) time.Duration {
+	value := getEnv(key)
+	if value == "" {
+		return fallback
+	}
+
+	result, err := time.ParseDuration(value)
+	if err != nil {
+		log.Fatalf("Error environment variable %s is not a duration", key)
+	}
+	return result
+}
+
+func getEnvBool(key string, fallback bool) bool {
+	value := getEnv(key)
+	if value == "" {
+		return fallback
+	}
+
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		log.Fatalf("Error environment variable %s is not a boolean", key)
+	}
+	return result
+}
+
+func getEnvFloat(key string, fallback float64) float64 {
+	value := getEnv(key)
+	if value == "" {
+		return fallback
+	}
+
+	result, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		log.Fatalf("Error environment variable %s is not a number", key)
+	}
+	return result
+}
@@ -0,0 +1,206 @@
+package markdown
+
+import (
+	"errors"
+	"html"
+	"net/url"
+	"strings"
+)
+
+// Schemes a link may point to, anything else is rendered as plain text
+var allowedSchemes = map[string]bool{
+	"http":  true,
+	"https": true,
+}
+
+var ErrUnsafeLink = errors.New("link must be an absolute http or https url")
+
+// Render converts the supported Markdown subset (bold, italics, code, links and lists) to HTML.
+// All input text is escaped and the result is passed through Sanitize as a second line of defence.
+func Render(text string) string {
+	var b strings.Builder
+	lines := strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n")
+
+	for i := 0; i < len(lines); {
+		line := strings.TrimSpace(lines[i])
+		switch {
+		case line == "":
+			i++
+		case listItem(line, false) != "":
+			i = renderList(&b, lines, i, false)
+		case listItem(line, true) != "":
+			i = renderList(&b, lines, i, true)
+		default:
+			i = renderParagraph(&b, lines, i)
+		}
+	}
+	return Sanitize(b.String())
+}
+
+// Validate reports an error when the text contains a link that can not be rendered safely
+func Validate(text string) error {
+	for _, line := range strings.Split(text, "\n") {
+		for i := 0; i < len(line); i++ {
+			if line[i] == '\\' {
+				i++
+				continue
+			}
+			if line[i] != '[' {
+				continue
+			}
+			_, href, n, ok := parseLink(line[i:])
+			if !ok {
+				continue
+			}
+			if !safeURL(href) {
+				return ErrUnsafeLink
+			}
+			i += n - 1
+		}
+	}
+	return nil
+}
+
+func renderList(b *strings.Builder, lines []string, i int, ordered bool) int {
+	tag := "ul"
+	if ordered {
+		tag = "ol"
+	}
+	b.WriteString("<" + tag + ">")
+	for ; i < len(lines); i++ {
+		item := listItem(strings.TrimSpace(lines[i]), ordered)
+		if item == "" {
+			break
+		}
+		b.WriteString("<li>")
+		renderInline(b, item)
+		b.WriteString("</li>")
+	}
+	b.WriteString("</" + tag + ">")
+	return i
+}
+
+func renderParagraph(b *strings.Builder, lines []string, i int) int {
+	b.WriteString("<p>")
+	for first := true; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || listItem(line, false) != "" || listItem(line, true) != "" {
+			break
+		}
+		if !first {
+			b.WriteString("<br>")
+		}
+		renderInline(b, line)
+		first = false
+	}
+	b.WriteString("</p>")
+	return i
+}
+
+// Return the content of a list item line, empty when the line is not a list item
+func listItem(line string, ordered bool) string {
+	if !ordered {
+		if strings.HasPrefix(line, "- ") || strings.HasPrefix(line, "* ") {
+			return strings.TrimSpace(line[2:])
+		}
+		return ""
+	}
+
+	digits := 0
+	for digits < len(line) && digits < 9 && line[digits] >= '0' && line[digits] <= '9' {
+		digits++
+	}
+	if digits == 0 || !strings.HasPrefix(line[digits:], ". ") {
+		return ""
+	}
+	return strings.TrimSpace(line[digits+2:])
+}
+
+func renderInline(b *strings.Builder, text string) {
+	for i := 0; i < len(text); {
+		switch c := text[i]; {
+		case c == '\\' && i+1 < len(text) && isPunct(text[i+1]):
+			b.WriteString(html.EscapeString(text[i+1 : i+2]))
+			i += 2
+		case c == '`':
+			if end := strings.IndexByte(text[i+1:], '`'); end > 0 {
+				b.WriteString("<code>" + html.EscapeString(text[i+1:i+1+end]) + "</code>")
+				i += end + 2
+				continue
+			}
+			b.WriteString("`")
+			i++
+		case c == '*' && strings.HasPrefix(text[i:], "**"):
+			if end := strings.Index(text[i+2:], "**"); end > 0 {
+				b.WriteString("<strong>")
+				renderInline(b, text[i+2:i+2+end])
+				b.WriteString("</strong>")
+				i += end + 4
+				continue
+			}
+			b.WriteString("**")
+			i += 2
+		case c == '*' || c == '_':
+			if end := strings.IndexByte(text[i+1:], c); end > 0 {
+				b.WriteString("<em>")
+				renderInline(b, text[i+1:i+1+end])
+				b.WriteString("</em>")
+				i += end + 2
+				continue
+			}
+			b.WriteByte(c)
+			i++
+		case c == '[':
+			if label, href, n, ok := parseLink(text[i:]); ok && safeURL(href) {
+				b.WriteString(`<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">`)
+				b.WriteString(html.EscapeString(label))
+				b.WriteString("</a>")
+				i += n
+				continue
+			}
+			b.WriteString("[")
+			i++
+		default:
+			// Copy up to the next special character in one go
+			end := i + 1
+			for end < len(text) && !isSpecial(text[end]) {
+				end++
+			}
+			b.WriteString(html.EscapeString(text[i:end]))
+			i = end
+		}
+	}
+}
+
+// Parse a [label](href) link at the start of text, returning the number of bytes consumed
+func parseLink(text string) (label string, href string, n int, ok bool) {
+	closeLabel := strings.Index(text, "](")
+	if closeLabel < 1 || strings.ContainsAny(text[1:closeLabel], "[]") {
+		return "", "", 0, false
+	}
+	closeHref := strings.IndexByte(text[closeLabel+2:], ')')
+	if closeHref < 1 {
+		return "", "", 0, false
+	}
+	href = text[closeLabel+2 : closeLabel+2+closeHref]
+	if strings.ContainsAny(href, " \t") {
+		return "", "", 0, false
+	}
+	return text[1:closeLabel], href, closeLabel + 3 + closeHref, true
+}
+
+func safeURL(href string) bool {
+	u, err := url.Parse(href)
+	if err != nil || u.Host == "" {
+		return false
+	}
+	return allowedSchemes[strings.ToLower(u.Scheme)]
+}
+
+func isSpecial(c byte) bool {
+	return c == '\\' || c == '`' || c == '*' || c == '_' || c == '['
+}
+
+func isPunct(c byte) bool {
+	return strings.IndexByte("\\`*_[]()#+-.!", c) >= 0
+}
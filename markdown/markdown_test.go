@@ -0,0 +1,102 @@
+package markdown
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	xhtml "golang.org/x/net/html"
+)
+
+// will test rendering of the supported subset
+func TestRender(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected string
+	}{
+		{"plain", "Good Job!", "<p>Good Job!</p>"},
+		{"bold", "**fast** bike", "<p><strong>fast</strong> bike</p>"},
+		{"italics", "a *nice* _mod_", "<p>a <em>nice</em> <em>mod</em></p>"},
+		{"code", "run `install.bat`", "<p>run <code>install.bat</code></p>"},
+		{"link", "[docs](https://mxb-mods.com/a?b=1&c=2)", `<p><a href="https://mxb-mods.com/a?b=1&amp;c=2" rel="nofollow ugc noopener noreferrer">docs</a></p>`},
+		{"unordered list", "steps:\n- unzip\n- copy", "<p>steps:</p><ul><li>unzip</li><li>copy</li></ul>"},
+		{"ordered list", "1. unzip\n2. copy", "<ol><li>unzip</li><li>copy</li></ol>"},
+		{"line break", "line 1\nline 2\n\nnext", "<p>line 1<br>line 2</p><p>next</p>"},
+		{"escaped", `\*not italic\*`, "<p>*not italic*</p>"},
+		{"html", "<b onclick=x>hi</b>", "<p>&lt;b onclick=x&gt;hi&lt;/b&gt;</p>"},
+		{"script", "<script>alert(1)</script>", "<p>&lt;script&gt;alert(1)&lt;/script&gt;</p>"},
+		{"javascript link", "[x](javascript:alert(1))", "<p>[x](javascript:alert(1))</p>"},
+		{"quote in link", `[x](https://a.com/"onmouseover="alert(1))`, `<p><a href="https://a.com/&#34;onmouseover=&#34;alert(1" rel="nofollow ugc noopener noreferrer">x</a>)</p>`},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Render(test.text))
+		})
+	}
+}
+
+// will test validation of links
+func TestValidate(t *testing.T) {
+	assert.NoError(t, Validate("see [docs](https://mxb-mods.com) and *more*"))
+	assert.NoError(t, Validate(`\[x](javascript:alert(1))`))
+	assert.ErrorIs(t, Validate("[x](javascript:alert(1))"), ErrUnsafeLink)
+	assert.ErrorIs(t, Validate("[x](/relative)"), ErrUnsafeLink)
+}
+
+// will test sanitising of arbitrary html
+func TestSanitize(t *testing.T) {
+	assert.Equal(t, "<p>hi</p>", Sanitize(`<p style="x">hi<script>alert(1)</script></p>`))
+	assert.Equal(t, `<a rel="nofollow ugc noopener noreferrer">x</a>`, Sanitize(`<a href="javascript:alert(1)" onclick="y">x</a>`))
+	assert.Equal(t, "&lt;img&gt;", Sanitize(`<img src=x onerror=alert(1)>&lt;img&gt;`))
+}
+
+func FuzzRender(f *testing.F) {
+	f.Add("**bold** *em* `code` [link](https://a.com)\n- a\n1. b")
+	f.Add(`<script>alert(1)</script>[x](javascript:alert(1))`)
+	f.Add(`[x](https://a.com/"><img src=x onerror=alert(1)>)`)
+	f.Fuzz(func(t *testing.T, text string) {
+		assertSafe(t, Render(text))
+	})
+}
+
+func FuzzSanitize(f *testing.F) {
+	f.Add(`<p>hi</p><a href="https://a.com">x</a>`)
+	f.Add(`<script>alert(1)</script><img src=x onerror=alert(1)>`)
+	f.Add(`<a href="java&#09;script:alert(1)">x</a><style>*{}</style>`)
+	f.Fuzz(func(t *testing.T, fragment string) {
+		sanitized := Sanitize(fragment)
+		assertSafe(t, sanitized)
+		assert.Equal(t, sanitized, Sanitize(sanitized))
+	})
+}
+
+// Assert that a fragment only contains allowed tags, safe links and no other attributes
+func assertSafe(t *testing.T, fragment string) {
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(fragment))
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == xhtml.ErrorToken {
+			return
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken, xhtml.EndTagToken:
+			if !allowedTags[token.Data] {
+				t.Fatalf("tag %q is not allowed in %q", token.Data, fragment)
+			}
+			for _, attr := range token.Attr {
+				switch {
+				case token.Data == "a" && attr.Key == "rel" && attr.Val == linkRel:
+				case token.Data == "a" && attr.Key == "href" && safeURL(attr.Val):
+				default:
+					t.Fatalf("attribute %q=%q is not allowed in %q", attr.Key, attr.Val, fragment)
+				}
+			}
+		case xhtml.CommentToken, xhtml.DoctypeToken:
+			t.Fatalf("token %q is not allowed in %q", token.String(), fragment)
+		}
+	}
+}
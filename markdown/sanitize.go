@@ -0,0 +1,87 @@
+package markdown
+
+import (
+	"html"
+	"strings"
+
+	xhtml "golang.org/x/net/html"
+)
+
+// Tags that may appear in rendered comment text
+var allowedTags = map[string]bool{
+	"p":      true,
+	"br":     true,
+	"strong": true,
+	"em":     true,
+	"code":   true,
+	"a":      true,
+	"ul":     true,
+	"ol":     true,
+	"li":     true,
+}
+
+// Rel attribute forced on every link
+const linkRel = "nofollow ugc noopener noreferrer"
+
+// Sanitize drops every tag and attribute outside of the Markdown subset from a HTML fragment.
+// Text is re-escaped, links keep only a safe href and always get rel=nofollow.
+func Sanitize(fragment string) string {
+	var b strings.Builder
+	tokenizer := xhtml.NewTokenizer(strings.NewReader(fragment))
+
+	// Text inside these elements is dropped together with the element
+	skipDepth := 0
+	for {
+		tokenType := tokenizer.Next()
+		if tokenType == xhtml.ErrorToken {
+			return b.String()
+		}
+
+		token := tokenizer.Token()
+		switch tokenType {
+		case xhtml.StartTagToken, xhtml.SelfClosingTagToken:
+			if token.Data == "script" || token.Data == "style" {
+				if tokenType == xhtml.StartTagToken {
+					skipDepth++
+				}
+				continue
+			}
+			if skipDepth > 0 || !allowedTags[token.Data] {
+				continue
+			}
+			b.WriteString(startTag(token))
+		case xhtml.EndTagToken:
+			if token.Data == "script" || token.Data == "style" {
+				if skipDepth > 0 {
+					skipDepth--
+				}
+				continue
+			}
+			if skipDepth > 0 || !allowedTags[token.Data] || token.Data == "br" {
+				continue
+			}
+			b.WriteString("</" + token.Data + ">")
+		case xhtml.TextToken:
+			if skipDepth == 0 {
+				b.WriteString(html.EscapeString(token.Data))
+			}
+		}
+	}
+}
+
+func startTag(token xhtml.Token) string {
+	if token.Data != "a" {
+		return "<" + token.Data + ">"
+	}
+
+	href := ""
+	for _, attr := range token.Attr {
+		if attr.Namespace == "" && attr.Key == "href" && safeURL(attr.Val) {
+			href = attr.Val
+		}
+	}
+	if href == "" {
+		return `<a rel="` + linkRel + `">`
+	}
+	return `<a href="` + html.EscapeString(href) + `" rel="` + linkRel + `">`
+}
@@ -0,0 +1,30 @@
+package mentions
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Maximum number of users that can be mentioned in one comment
+const MaxMentions = 10
+
+// A mention is an @ that does not follow a word character, like an email address does
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@([A-Za-z0-9_][A-Za-z0-9_.-]{1,31})`)
+
+// Parse returns the distinct lower case names mentioned in text, in order of appearance
+func Parse(text string) []string {
+	var names []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		name := strings.ToLower(strings.TrimRight(match[1], ".-"))
+		if len(name) < 2 || seen[name] {
+			continue
+		}
+		seen[name] = true
+		names = append(names, name)
+		if len(names) == MaxMentions {
+			break
+		}
+	}
+	return names
+}
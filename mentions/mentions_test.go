@@ -0,0 +1,28 @@
+package mentions
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// will test parsing of mentions
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"none", "Good Job!", nil},
+		{"single", "@Rider42 thanks!", []string{"rider42"}},
+		{"distinct", "@a_b and @A_B, @cd.", []string{"a_b", "cd"}},
+		{"email", "mail me at rider@mxbikes.com", nil},
+		{"too short", "@x", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Parse(test.text))
+		})
+	}
+}
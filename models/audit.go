@@ -0,0 +1,190 @@
+package models
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Audit actions
+const (
+	AuditActionCreate         = "comment.create"
+	AuditActionUpdate         = "comment.update"
+	AuditActionDelete         = "comment.delete"
+	AuditActionHide           = "comment.hide"
+	AuditActionUnhide         = "comment.unhide"
+	AuditActionPin            = "comment.pin"
+	AuditActionUnpin          = "comment.unpin"
+	AuditActionApprove        = "comment.approve"
+	AuditActionReject         = "comment.reject"
+	AuditActionModerationMode = "mod.moderation_mode"
+	AuditActionBlock          = "user.block"
+	AuditActionUnblock        = "user.unblock"
+	AuditActionExportUser     = "user.export"
+	AuditActionEraseUser      = "user.erase"
+	AuditActionShadowBan      = "user.shadow_ban"
+	AuditActionShadowLift     = "user.shadow_lift"
+	AuditActionBulkDelete     = "comment.bulk_delete"
+	AuditActionBulkHide       = "comment.bulk_hide"
+	AuditActionImport         = "comment.import"
+	AuditActionRestore        = "comment.restore"
+	AuditActionPurge          = "comment.purge"
+)
+
+// An append-only record of a mutation. Entries are numbered without gaps and each hash covers the entry and the
+// hash of the one before it, so a changed or removed entry breaks the chain from there on.
+type AuditLog struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	Sequence  int64     `gorm:"uniqueIndex"`
+	Actor     string    `gorm:"type:varchar(50);not null;index"`
+	Action    string    `gorm:"type:varchar(50);not null;index"`
+	Target    string    `gorm:"type:varchar(50);index"`
+	Before    string    `gorm:"type:text;not null;default:''"`
+	After     string    `gorm:"type:text;not null;default:''"`
+	Detail    string    `gorm:"type:text"`
+	RequestID string    `gorm:"type:varchar(100);not null;default:'';index"`
+	ClientIP  string    `gorm:"type:varchar(45);not null;default:''"`
+	CreatedAt time.Time `gorm:"index"`
+	PrevHash  string    `gorm:"type:varchar(64);not null;default:''"`
+	Hash      string    `gorm:"type:varchar(64);not null;default:''"`
+}
+
+// What an audit entry keeps of a comment. Entries can never be erased, so the snapshot holds
+// no author and only the hash of the text, which still shows whether the text changed.
+type CommentSnapshot struct {
+	ID       string `json:"id"`
+	ModID    string `json:"modID"`
+	Status   string `json:"status,omitempty"`
+	Hidden   bool   `json:"hidden"`
+	Version  int64  `json:"version"`
+	TextHash string `json:"textHash"`
+}
+
+// Returns the JSON snapshot of a comment kept by an audit entry, empty when there is no comment
+func AuditSnapshot(comment *Comment) (string, error) {
+	if comment == nil {
+		return "", nil
+	}
+	data, err := json.Marshal(CommentToSnapshot(comment))
+	return string(data), err
+}
+
+func CommentToSnapshot(comment *Comment) *CommentSnapshot {
+	return &CommentSnapshot{
+		ID:       comment.ID,
+		ModID:    comment.ModID,
+		Status:   comment.Status,
+		Hidden:   comment.Hidden,
+		Version:  comment.Version,
+		TextHash: TextHash(comment.Text),
+	}
+}
+
+// Returns the hex SHA-256 of a text, kept by audit entries instead of the text itself
+func TextHash(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return hex.EncodeToString(sum[:])
+}
+
+// Returns the hash of the entry chained to the hash before it, every field is length prefixed
+func (a *AuditLog) ComputeHash() string {
+	h := sha256.New()
+	for _, field := range []string{
+		strconv.FormatInt(a.Sequence, 10),
+		a.PrevHash,
+		a.Actor,
+		a.Action,
+		a.Target,
+		a.Before,
+		a.After,
+		a.Detail,
+		a.RequestID,
+		a.ClientIP,
+		a.CreatedAt.UTC().Format(time.RFC3339Nano),
+	} {
+		fmt.Fprintf(h, "%d:%s", len(field), field)
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// Selects audit entries, empty fields select every entry
+type AuditFilter struct {
+	Actor     string
+	Action    string
+	Target    string
+	RequestID string
+	Since     time.Time
+	Until     time.Time
+}
+
+// Outcome of checking the hash chain, BrokenAt is the sequence of the first entry that does not fit
+type AuditVerification struct {
+	Entries  int64  `json:"entries"`
+	LastHash string `json:"lastHash"`
+	BrokenAt int64  `json:"brokenAt,omitempty"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+func AuditFilterFromProto(req *protobuffer.QueryAuditLogRequest) AuditFilter {
+	filter := AuditFilter{Actor: req.Actor, Action: req.Action, Target: req.Target, RequestID: req.RequestID}
+	if req.Since != nil {
+		filter.Since = req.Since.AsTime()
+	}
+	if req.Until != nil {
+		filter.Until = req.Until.AsTime()
+	}
+	return filter
+}
+
+func AuditLogToProto(entry *AuditLog) *protobuffer.AuditEntry {
+	return &protobuffer.AuditEntry{
+		ID:        entry.ID,
+		Sequence:  entry.Sequence,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Before:    entry.Before,
+		After:     entry.After,
+		Detail:    entry.Detail,
+		RequestID: entry.RequestID,
+		ClientIP:  entry.ClientIP,
+		Create_At: timestamppb.New(entry.CreatedAt),
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	}
+}
+
+func AuditLogsToProto(entries []*AuditLog) []*protobuffer.AuditEntry {
+	result := make([]*protobuffer.AuditEntry, 0, len(entries))
+	for _, entry := range entries {
+		result = append(result, AuditLogToProto(entry))
+	}
+	return result
+}
+
+func AuditLogFromProto(entry *protobuffer.AuditEntry) *AuditLog {
+	result := &AuditLog{
+		ID:        entry.ID,
+		Sequence:  entry.Sequence,
+		Actor:     entry.Actor,
+		Action:    entry.Action,
+		Target:    entry.Target,
+		Before:    entry.Before,
+		After:     entry.After,
+		Detail:    entry.Detail,
+		RequestID: entry.RequestID,
+		ClientIP:  entry.ClientIP,
+		PrevHash:  entry.PrevHash,
+		Hash:      entry.Hash,
+	}
+	if entry.Create_At != nil {
+		result.CreatedAt = entry.Create_At.AsTime()
+	}
+	return result
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/google/uuid"
+	"github.com/stretchr/testify/assert"
+)
+
+// will test the snapshot of a comment keeps neither its author nor its text
+func TestAuditSnapshot(t *testing.T) {
+	// Arrange
+	comment := &Comment{ID: uuid.NewString(), ModID: uuid.NewString(), UserID: "63b2dff9e834e550f0e50e66", Text: "call me on 555-0100", Version: 2}
+
+	// Act
+	data, err := AuditSnapshot(comment)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Contains(t, data, comment.ID)
+	assert.NotContains(t, data, comment.UserID)
+	assert.NotContains(t, data, comment.Text)
+	assert.Contains(t, data, CommentToSnapshot(comment).TextHash)
+}
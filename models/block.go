@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// A user that no longer wants to see or be mentioned by another user
+type Block struct {
+	BlockerID string    `gorm:"type:varchar(50);primaryKey"`
+	BlockedID string    `gorm:"type:varchar(50);primaryKey;index"`
+	CreatedAt time.Time `gorm:"index"`
+}
+
+func BlockToProto(block *Block) *protobuffer.BlockedUser {
+	return &protobuffer.BlockedUser{
+		UserID:    block.BlockedID,
+		Create_At: timestamppb.New(block.CreatedAt),
+	}
+}
+
+func BlocksToProto(blocks []*Block) []*protobuffer.BlockedUser {
+	result := make([]*protobuffer.BlockedUser, 0, len(blocks))
+	for _, block := range blocks {
+		result = append(result, BlockToProto(block))
+	}
+	return result
+}
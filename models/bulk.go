@@ -0,0 +1,43 @@
+package models
+
+import (
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+)
+
+// Bounds of bulk moderation
+const (
+	MaxBulkIDs           = 1000
+	MaxBulkComments      = 10000
+	MaxTextPatternLength = 200
+)
+
+// Selects the comments of a bulk moderation or export, TextPattern is a case-insensitive regular expression
+type CommentFilter struct {
+	UserID         string
+	ModID          string
+	Since          time.Time
+	Until          time.Time
+	TextPattern    string
+	IncludeDeleted bool
+}
+
+func CommentFilterFromProto(filter *protobuffer.CommentFilter) CommentFilter {
+	if filter == nil {
+		return CommentFilter{}
+	}
+	result := CommentFilter{UserID: filter.UserID, ModID: filter.ModID, TextPattern: filter.TextPattern}
+	if filter.Since != nil {
+		result.Since = filter.Since.AsTime()
+	}
+	if filter.Until != nil {
+		result.Until = filter.Until.AsTime()
+	}
+	return result
+}
+
+// Report whether the filter selects by author, mod or text, a time range alone selects too much
+func (f CommentFilter) Narrow() bool {
+	return f.UserID != "" || f.ModID != "" || f.TextPattern != ""
+}
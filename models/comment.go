@@ -1,17 +1,27 @@
 package models
 
 import (
-	protobuffer "github.com/mxbikes/protobuf/comment"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/markdown"
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
 	"google.golang.org/protobuf/types/known/timestamppb"
 	"gorm.io/gorm"
 )
 
 type Comment struct {
-	gorm.Model
-	ID     string `gorm:"type:uuid;default:uuid_generate_v4()" validate:"omitempty,uuid4"`
-	ModID  string `gorm:"type:uuid;" validate:"uuid4,required"`
-	UserID string `gorm:"type:varchar(50);not null;default:null" validate:"required"`
-	Text   string `gorm:"type:varchar(250);not null;default:null" validate:"min=1,max=250"`
+	ID        string         `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()" validate:"omitempty,uuid4"`
+	CreatedAt time.Time      `gorm:"not null"`
+	UpdatedAt time.Time      `gorm:"not null"`
+	DeletedAt gorm.DeletedAt `gorm:"index"`
+	ModID     string         `gorm:"type:uuid;not null" validate:"uuid4,required"`
+	UserID    string         `gorm:"type:varchar(50);not null;default:null;index" validate:"required"`
+	Text      string         `gorm:"type:text;not null;default:null;check:chk_comments_text_size,octet_length(text) <= 4096" validate:"textmin,textmax,visible,markdown"`
+	Hidden    bool           `gorm:"not null;default:false"`
+	Status    string         `gorm:"type:varchar(20);not null;default:'published';index"`
+	SpamScore *float64       `gorm:"type:real"`
+	Version   int64          `gorm:"not null;default:1"`
+	Pinned    bool           `gorm:"-"`
 }
 
 func CommentToProto(comment *Comment) *protobuffer.Comment {
@@ -20,7 +30,12 @@ func CommentToProto(comment *Comment) *protobuffer.Comment {
 		ModID:     comment.ModID,
 		UserID:    comment.UserID,
 		Text:      comment.Text,
+		HTML:      markdown.Render(comment.Text),
 		Create_At: timestamppb.New(comment.CreatedAt),
+		Hidden:    comment.Hidden,
+		Pinned:    comment.Pinned,
+		Status:    StatusToProto(comment.Status),
+		Version:   comment.Version,
 	}
 }
 
@@ -31,3 +46,20 @@ func CommentsToProto(comments []*Comment) []*protobuffer.Comment {
 	}
 	return result
 }
+
+func CommentFromProto(comment *protobuffer.Comment) *Comment {
+	result := &Comment{
+		ID:      comment.ID,
+		ModID:   comment.ModID,
+		UserID:  comment.UserID,
+		Text:    comment.Text,
+		Hidden:  comment.Hidden,
+		Status:  StatusFromProto(comment.Status),
+		Version: comment.Version,
+		Pinned:  comment.Pinned,
+	}
+	if comment.Create_At != nil {
+		result.CreatedAt = comment.Create_At.AsTime()
+	}
+	return result
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"time"
+)
+
+// Identity that replaces the UserID of anonymised comments
+const TombstoneUserID = "deleted-user"
+
+type UserExport struct {
+	UserID          string                   `json:"userId"`
+	ExportedAt      time.Time                `json:"exportedAt"`
+	Comments        []ExportedComment        `json:"comments"`
+	Mentions        []ExportedMention        `json:"mentions"`
+	Blocks          []ExportedBlock          `json:"blocks"`
+	ShadowBan       *ExportedShadowBan       `json:"shadowBan,omitempty"`
+	Reviews         []ExportedReview         `json:"reviews"`
+	IdempotencyKeys []ExportedIdempotencyKey `json:"idempotencyKeys"`
+}
+
+// Rows that carry the id of a user outside its comments
+type UserRecords struct {
+	Mentions        []*Mention
+	Blocks          []*Block
+	ShadowBan       *ShadowBan
+	Reviews         []*Review
+	IdempotencyKeys []*IdempotencyKey
+}
+
+type ExportedComment struct {
+	ID        string     `json:"id"`
+	ModID     string     `json:"modId"`
+	Text      string     `json:"text"`
+	CreatedAt time.Time  `json:"createdAt"`
+	UpdatedAt time.Time  `json:"updatedAt"`
+	DeletedAt *time.Time `json:"deletedAt,omitempty"`
+}
+
+// A mention written by the user, or of the user by another author
+type ExportedMention struct {
+	CommentID string    `json:"commentId"`
+	ModID     string    `json:"modId"`
+	AuthorID  string    `json:"authorId"`
+	UserID    string    `json:"userId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A user blocked by the user, who blocked the user is not exported
+type ExportedBlock struct {
+	BlockedID string    `json:"blockedId"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ExportedShadowBan struct {
+	Reason    string    `json:"reason"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+// A review decision on a comment of the user
+type ExportedReview struct {
+	CommentID string    `json:"commentId"`
+	Status    string    `json:"status"`
+	Reason    string    `json:"reason,omitempty"`
+	CreatedAt time.Time `json:"createdAt"`
+}
+
+type ExportedIdempotencyKey struct {
+	Key       string    `json:"key"`
+	CommentID string    `json:"commentId"`
+	CreatedAt time.Time `json:"createdAt"`
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+func UserDataToExport(userID string, comments []*Comment, records *UserRecords) *UserExport {
+	result := commentsToUserExport(userID, comments)
+	for _, mention := range records.Mentions {
+		result.Mentions = append(result.Mentions, ExportedMention{
+			CommentID: mention.CommentID,
+			ModID:     mention.ModID,
+			AuthorID:  mention.AuthorID,
+			UserID:    mention.UserID,
+			CreatedAt: mention.CreatedAt,
+		})
+	}
+	for _, block := range records.Blocks {
+		if block.BlockerID == userID {
+			result.Blocks = append(result.Blocks, ExportedBlock{BlockedID: block.BlockedID, CreatedAt: block.CreatedAt})
+		}
+	}
+	if records.ShadowBan != nil {
+		result.ShadowBan = &ExportedShadowBan{Reason: records.ShadowBan.Reason, CreatedAt: records.ShadowBan.CreatedAt}
+	}
+	for _, review := range records.Reviews {
+		result.Reviews = append(result.Reviews, ExportedReview{
+			CommentID: review.CommentID,
+			Status:    review.Status,
+			Reason:    review.Reason,
+			CreatedAt: review.CreatedAt,
+		})
+	}
+	for _, key := range records.IdempotencyKeys {
+		result.IdempotencyKeys = append(result.IdempotencyKeys, ExportedIdempotencyKey{
+			Key:       key.Key,
+			CommentID: key.CommentID,
+			CreatedAt: key.CreatedAt,
+			ExpiresAt: key.ExpiresAt,
+		})
+	}
+	return result
+}
+
+func commentsToUserExport(userID string, comments []*Comment) *UserExport {
+	result := &UserExport{
+		UserID:          userID,
+		ExportedAt:      time.Now().UTC(),
+		Comments:        make([]ExportedComment, 0, len(comments)),
+		Mentions:        []ExportedMention{},
+		Blocks:          []ExportedBlock{},
+		Reviews:         []ExportedReview{},
+		IdempotencyKeys: []ExportedIdempotencyKey{},
+	}
+	for _, comment := range comments {
+		exported := ExportedComment{
+			ID:        comment.ID,
+			ModID:     comment.ModID,
+			Text:      comment.Text,
+			CreatedAt: comment.CreatedAt,
+			UpdatedAt: comment.UpdatedAt,
+		}
+		if comment.DeletedAt.Valid {
+			exported.DeletedAt = &comment.DeletedAt.Time
+		}
+		result.Comments = append(result.Comments, exported)
+	}
+	return result
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// Maximum length of an idempotency key sent by a client
+const MaxIdempotencyKeyLength = 100
+
+// A CreateComment request made under an idempotency key. CommentID stays empty
+// while the request that reserved the key is still running.
+type IdempotencyKey struct {
+	CallerID    string `gorm:"type:varchar(50);primaryKey"`
+	Key         string `gorm:"type:varchar(100);primaryKey"`
+	RequestHash string `gorm:"type:char(64);not null"`
+	CommentID   string `gorm:"type:varchar(36);not null"`
+	Status      string `gorm:"type:varchar(20);not null"`
+	CreatedAt   time.Time
+	ExpiresAt   time.Time `gorm:"index"`
+}
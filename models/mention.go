@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type Mention struct {
+	ID        string    `gorm:"type:uuid;primaryKey;default:uuid_generate_v4()"`
+	CommentID string    `gorm:"type:uuid;not null;uniqueIndex:idx_mentions_comment_id_user_id"`
+	ModID     string    `gorm:"type:uuid;not null"`
+	AuthorID  string    `gorm:"type:varchar(50);not null"`
+	UserID    string    `gorm:"type:varchar(50);not null;uniqueIndex:idx_mentions_comment_id_user_id;index:idx_mentions_user_id_created_at,priority:1"`
+	CreatedAt time.Time `gorm:"index:idx_mentions_user_id_created_at,priority:2"`
+	Comment   *Comment  `gorm:"-"`
+}
+
+func MentionToProto(mention *Mention) *protobuffer.Mention {
+	result := &protobuffer.Mention{
+		ID:        mention.ID,
+		CommentID: mention.CommentID,
+		ModID:     mention.ModID,
+		AuthorID:  mention.AuthorID,
+		Create_At: timestamppb.New(mention.CreatedAt),
+	}
+	if mention.Comment != nil {
+		result.Comment = CommentToProto(mention.Comment)
+	}
+	return result
+}
+
+func MentionsToProto(mentions []*Mention) []*protobuffer.Mention {
+	result := make([]*protobuffer.Mention, 0, len(mentions))
+	for _, mention := range mentions {
+		result = append(result, MentionToProto(mention))
+	}
+	return result
+}
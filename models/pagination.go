@@ -0,0 +1,45 @@
+package models
+
+import (
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+)
+
+// Defaults and bounds of a paginated request
+const (
+	DefaultPageSize = 20
+	MaxPageSize     = 100
+)
+
+type Pagination struct {
+	Page int
+	Size int
+}
+
+// Return a pagination with defaults applied to page and size
+func NewPagination(page int64, size int64) Pagination {
+	if page < 1 {
+		page = 1
+	}
+	if size < 1 {
+		size = DefaultPageSize
+	}
+	if size > MaxPageSize {
+		size = MaxPageSize
+	}
+	return Pagination{Page: int(page), Size: int(size)}
+}
+
+func (p Pagination) Offset() int {
+	return (p.Page - 1) * p.Size
+}
+
+func PaginationToProto(pagination Pagination, totalCount int64) *protobuffer.Pagination {
+	totalPages := (totalCount + int64(pagination.Size) - 1) / int64(pagination.Size)
+	return &protobuffer.Pagination{
+		TotalCount: totalCount,
+		TotalPages: totalPages,
+		Page:       int64(pagination.Page),
+		Size:       int64(pagination.Size),
+		HasMore:    int64(pagination.Page) < totalPages,
+	}
+}
@@ -0,0 +1,16 @@
+package models
+
+import (
+	"time"
+)
+
+// Maximum number of comments that can be pinned on one mod
+const MaxPinsPerMod = 3
+
+type Pin struct {
+	CommentID string `gorm:"type:uuid;primaryKey"`
+	ModID     string `gorm:"type:uuid;not null;index"`
+	Position  int    `gorm:"not null"`
+	PinnedBy  string `gorm:"type:varchar(50);not null"`
+	CreatedAt time.Time
+}
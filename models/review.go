@@ -0,0 +1,58 @@
+package models
+
+import (
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+)
+
+// Moderation states of a comment
+const (
+	StatusPublished = "published"
+	StatusPending   = "pending"
+	StatusRejected  = "rejected"
+)
+
+// Maximum length of the reason recorded with a rejection
+const MaxReviewReasonLength = 500
+
+// A moderator decision on a pending comment
+type Review struct {
+	CommentID  string `gorm:"type:uuid;primaryKey"`
+	ModID      string `gorm:"type:uuid;not null"`
+	UserID     string `gorm:"type:varchar(50);not null;index:idx_reviews_user_id_status,priority:1"`
+	Status     string `gorm:"type:varchar(20);not null;index:idx_reviews_user_id_status,priority:2"`
+	ReviewedBy string `gorm:"type:varchar(50);not null"`
+	Reason     string `gorm:"type:text"`
+	CreatedAt  time.Time
+}
+
+// Moderation settings of a single mod
+type ModerationSetting struct {
+	ModID         string `gorm:"type:uuid;primaryKey"`
+	PreModeration bool   `gorm:"not null"`
+	UpdatedBy     string `gorm:"type:varchar(50);not null"`
+	UpdatedAt     time.Time
+}
+
+func StatusToProto(status string) protobuffer.CommentStatus {
+	switch status {
+	case StatusPending:
+		return protobuffer.CommentStatus_PENDING
+	case StatusRejected:
+		return protobuffer.CommentStatus_REJECTED
+	default:
+		return protobuffer.CommentStatus_PUBLISHED
+	}
+}
+
+func StatusFromProto(status protobuffer.CommentStatus) string {
+	switch status {
+	case protobuffer.CommentStatus_PENDING:
+		return StatusPending
+	case protobuffer.CommentStatus_REJECTED:
+		return StatusRejected
+	default:
+		return StatusPublished
+	}
+}
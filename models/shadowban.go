@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	protobuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/comment"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// Maximum length of the reason recorded with a shadow-ban
+const MaxShadowBanReasonLength = 500
+
+// A user whose comments are only shown to themself and moderators
+type ShadowBan struct {
+	UserID    string `gorm:"type:varchar(50);primaryKey"`
+	BannedBy  string `gorm:"type:varchar(50);not null"`
+	Reason    string `gorm:"type:text"`
+	CreatedAt time.Time
+}
+
+func ShadowBanToProto(ban *ShadowBan) *protobuffer.ShadowBan {
+	return &protobuffer.ShadowBan{
+		UserID:    ban.UserID,
+		BannedBy:  ban.BannedBy,
+		Reason:    ban.Reason,
+		Create_At: timestamppb.New(ban.CreatedAt),
+	}
+}
+
+func ShadowBansToProto(bans []*ShadowBan) []*protobuffer.ShadowBan {
+	result := make([]*protobuffer.ShadowBan, 0, len(bans))
+	for _, ban := range bans {
+		result = append(result, ShadowBanToProto(ban))
+	}
+	return result
+}
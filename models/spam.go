@@ -0,0 +1,7 @@
+package models
+
+// Text of a reviewed comment labelled with the moderator decision, used to train the spam model
+type LabeledText struct {
+	Text string
+	Spam bool
+}
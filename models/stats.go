@@ -0,0 +1,14 @@
+package models
+
+// Counts over every comment, deleted comments are only counted as deleted
+type CommentStats struct {
+	Total     int64 `json:"total"`
+	Published int64 `json:"published"`
+	Pending   int64 `json:"pending"`
+	Rejected  int64 `json:"rejected"`
+	Hidden    int64 `json:"hidden"`
+	Deleted   int64 `json:"deleted"`
+	Mods      int64 `json:"mods"`
+	Authors   int64 `json:"authors"`
+	LastDay   int64 `json:"lastDay"`
+}
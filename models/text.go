@@ -0,0 +1,47 @@
+package models
+
+import (
+	"strings"
+	"unicode"
+
+	"github.com/rivo/uniseg"
+	"golang.org/x/text/unicode/norm"
+)
+
+// Hard upper bound of the stored text in bytes, enforced by a check constraint on the column
+const MaxTextBytes = 4096
+
+// Default length limits of a comment text in grapheme clusters
+const (
+	DefaultMinTextLength = 1
+	DefaultMaxTextLength = 250
+)
+
+type TextLimits struct {
+	Min int
+	Max int
+}
+
+func DefaultTextLimits() TextLimits {
+	return TextLimits{Min: DefaultMinTextLength, Max: DefaultMaxTextLength}
+}
+
+// Normalise text to NFC and trim surrounding whitespace
+func NormalizeText(text string) string {
+	return strings.TrimSpace(norm.NFC.String(text))
+}
+
+// Return the number of user-perceived characters of text
+func TextLength(text string) int {
+	return uniseg.GraphemeClusterCount(text)
+}
+
+// Report whether text contains at least one character that is not whitespace, a control or a format character (zero width)
+func IsVisibleText(text string) bool {
+	for _, r := range text {
+		if !unicode.IsSpace(r) && !unicode.IsControl(r) && !unicode.Is(unicode.Cf, r) {
+			return true
+		}
+	}
+	return false
+}
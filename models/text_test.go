@@ -0,0 +1,27 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// will test normalising of text
+func TestNormalizeText(t *testing.T) {
+	assert.Equal(t, "caf\u00e9", NormalizeText(" \tcafe\u0301\n"))
+}
+
+// will test counting of grapheme clusters
+func TestTextLength(t *testing.T) {
+	assert.Equal(t, 4, TextLength("Good"))
+	assert.Equal(t, 1, TextLength("\U0001F468\u200d\U0001F469\u200d\U0001F467"))
+	assert.Equal(t, 2, TextLength("\U0001F3CD\U0001F3FD\U0001F1F3\U0001F1F1"))
+	assert.Equal(t, 1, TextLength("e\u0301"))
+}
+
+// will test detection of text without visible characters
+func TestIsVisibleText(t *testing.T) {
+	assert.True(t, IsVisibleText("\u200bhi"))
+	assert.False(t, IsVisibleText("\u200b\u200c\u200d\ufeff"))
+	assert.False(t, IsVisibleText("\x00\x07 \t"))
+}
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"github.com/go-playground/validator/v10"
+	"github.com/mxbikes/mxbikesclient.service.comment/markdown"
+)
+
+// Return a validator with the custom comment validations registered
+func NewValidator(limits TextLimits) *validator.Validate {
+	validate := validator.New()
+	validate.RegisterValidation("markdown", func(fl validator.FieldLevel) bool {
+		return markdown.Validate(fl.Field().String()) == nil
+	})
+	validate.RegisterValidation("textmin", func(fl validator.FieldLevel) bool {
+		return TextLength(fl.Field().String()) >= limits.Min
+	})
+	validate.RegisterValidation("textmax", func(fl validator.FieldLevel) bool {
+		text := fl.Field().String()
+		return len(text) <= MaxTextBytes && TextLength(text) <= limits.Max
+	})
+	validate.RegisterValidation("visible", func(fl validator.FieldLevel) bool {
+		return IsVisibleText(fl.Field().String())
+	})
+	return validate
+}
@@ -0,0 +1,50 @@
+package ownership
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+type cacheEntry struct {
+	owner   string
+	expires time.Time
+}
+
+type cachedOwnership struct {
+	next    ModOwnership
+	ttl     time.Duration
+	now     func() time.Time
+	mu      sync.Mutex
+	entries map[string]cacheEntry
+}
+
+// Return an ownership lookup that remembers the owners found by next for the ttl, errors are never cached
+func NewCache(next ModOwnership, ttl time.Duration) *cachedOwnership {
+	return &cachedOwnership{next: next, ttl: ttl, now: time.Now, entries: make(map[string]cacheEntry)}
+}
+
+func (c *cachedOwnership) OwnerOf(ctx context.Context, modID string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[modID]
+	c.mu.Unlock()
+	if ok && c.now().Before(entry.expires) {
+		return entry.owner, nil
+	}
+
+	owner, err := c.next.OwnerOf(ctx, modID)
+	if err != nil {
+		return "", err
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// Drop expired entries on the way so the cache does not grow without bound
+	for id, entry := range c.entries {
+		if !c.now().Before(entry.expires) {
+			delete(c.entries, id)
+		}
+	}
+	c.entries[modID] = cacheEntry{owner: owner, expires: c.now().Add(c.ttl)}
+	return owner, nil
+}
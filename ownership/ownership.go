@@ -0,0 +1,64 @@
+package ownership
+
+import (
+	"context"
+	"encoding/json"
+	"os"
+
+	modbuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/mod"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// ModOwnership looks up who owns a mod
+type ModOwnership interface {
+	// Return the user id of the owner of a mod, empty when the mod or its owner is unknown
+	OwnerOf(ctx context.Context, modID string) (string, error)
+}
+
+type grpcOwnership struct {
+	client modbuffer.ModServiceClient
+}
+
+// Return an ownership lookup backed by the mod service
+func NewGrpcOwnership(client modbuffer.ModServiceClient) *grpcOwnership {
+	return &grpcOwnership{client: client}
+}
+
+func (o *grpcOwnership) OwnerOf(ctx context.Context, modID string) (string, error) {
+	res, err := o.client.GetModByID(ctx, &modbuffer.GetModByIDRequest{ID: modID})
+	if status.Code(err) == codes.NotFound {
+		return "", nil
+	}
+	if err != nil {
+		return "", err
+	}
+	return res.GetMod().GetUserID(), nil
+}
+
+type staticOwnership struct {
+	owners map[string]string
+}
+
+// Return an ownership lookup backed by a JSON file mapping mod ids to owner user ids
+func NewFileOwnership(path string) (*staticOwnership, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var owners map[string]string
+	if err := json.Unmarshal(data, &owners); err != nil {
+		return nil, err
+	}
+	return NewStaticOwnership(owners), nil
+}
+
+// Return an ownership lookup backed by an in-memory map of mod ids to owner user ids
+func NewStaticOwnership(owners map[string]string) *staticOwnership {
+	return &staticOwnership{owners: owners}
+}
+
+func (o *staticOwnership) OwnerOf(ctx context.Context, modID string) (string, error) {
+	return o.owners[modID], nil
+}
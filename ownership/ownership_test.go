@@ -0,0 +1,106 @@
+package ownership
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	modbuffer "github.com/mxbikes/mxbikesclient.service.comment/protobuf/mod"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type countingOwnership struct {
+	calls int
+	owner string
+	err   error
+}
+
+func (o *countingOwnership) OwnerOf(ctx context.Context, modID string) (string, error) {
+	o.calls++
+	return o.owner, o.err
+}
+
+// Mod service answering GetModByID from a map of mod ids to owners
+type fakeModService struct {
+	owners map[string]string
+}
+
+func (s *fakeModService) GetModByID(ctx context.Context, in *modbuffer.GetModByIDRequest, opts ...grpc.CallOption) (*modbuffer.GetModByIDResponse, error) {
+	owner, ok := s.owners[in.ID]
+	if !ok {
+		return nil, status.Error(codes.NotFound, "mod not found")
+	}
+	return &modbuffer.GetModByIDResponse{Mod: &modbuffer.Mod{ID: in.ID, UserID: owner}}, nil
+}
+
+// will test owner lookup from the mod service, unknown mods have no owner
+func TestGrpcOwnership(t *testing.T) {
+	// Arrange
+	ownership := NewGrpcOwnership(&fakeModService{owners: map[string]string{"mod": "63b2dff9e834e550f0e50e66"}})
+
+	// Act
+	owner, err := ownership.OwnerOf(context.Background(), "mod")
+	unknown, unknownErr := ownership.OwnerOf(context.Background(), "unknown")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, unknownErr)
+	assert.Equal(t, owner, "63b2dff9e834e550f0e50e66")
+	assert.Empty(t, unknown)
+}
+
+// will test owner lookup from a file
+func TestFileOwnership(t *testing.T) {
+	// Arrange
+	ownership, err := NewFileOwnership("testdata/owners.json")
+	assert.NoError(t, err)
+
+	// Act
+	owner, err := ownership.OwnerOf(context.Background(), "2f6c1d4e-3b8a-4c51-9e8f-0a7b6c5d4e3f")
+	unknown, _ := ownership.OwnerOf(context.Background(), "unknown")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, owner, "63b2dff9e834e550f0e50e66")
+	assert.Empty(t, unknown)
+}
+
+// will test owners are cached until the ttl expires
+func TestCacheExpires(t *testing.T) {
+	// Arrange
+	next := &countingOwnership{owner: "owner"}
+	cache := NewCache(next, time.Minute)
+	now := time.Now()
+	cache.now = func() time.Time { return now }
+
+	// Act
+	cache.OwnerOf(context.Background(), "mod")
+	cache.OwnerOf(context.Background(), "mod")
+	now = now.Add(2 * time.Minute)
+	owner, err := cache.OwnerOf(context.Background(), "mod")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, owner, "owner")
+	assert.Equal(t, next.calls, 2)
+}
+
+// will test errors are not cached
+func TestCacheDoesNotCacheErrors(t *testing.T) {
+	// Arrange
+	next := &countingOwnership{err: errors.New("unavailable")}
+	cache := NewCache(next, time.Minute)
+
+	// Act
+	_, err1 := cache.OwnerOf(context.Background(), "mod")
+	_, err2 := cache.OwnerOf(context.Background(), "mod")
+
+	// Assert
+	assert.Error(t, err1)
+	assert.Error(t, err2)
+	assert.Equal(t, next.calls, 2)
+}
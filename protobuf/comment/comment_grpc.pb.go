@@ -0,0 +1,1148 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.2.0
+// - protoc             v3.21.7
+// source: protobuf/comment/comment.proto
+
+package comment
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.32.0 or later.
+const _ = grpc.SupportPackageIsVersion7
+
+// CommentServiceClient is the client API for CommentService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+type CommentServiceClient interface {
+	GetCommentByModID(ctx context.Context, in *GetCommentByModIDRequest, opts ...grpc.CallOption) (*GetCommentByModIDResponse, error)
+	GetCommentsByModIDs(ctx context.Context, in *GetCommentsByModIDsRequest, opts ...grpc.CallOption) (*GetCommentsByModIDsResponse, error)
+	GetCommentByID(ctx context.Context, in *GetCommentByIDRequest, opts ...grpc.CallOption) (*GetCommentByIDResponse, error)
+	GetCommentsByUserID(ctx context.Context, in *GetCommentsByUserIDRequest, opts ...grpc.CallOption) (*GetCommentsByUserIDResponse, error)
+	UpdateComment(ctx context.Context, in *UpdateCommentRequest, opts ...grpc.CallOption) (*UpdateCommentResponse, error)
+	DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*DeleteCommentResponse, error)
+	CreateComment(ctx context.Context, in *CreateCommentRequest, opts ...grpc.CallOption) (*CreateCommentResponse, error)
+	ExportUserComments(ctx context.Context, in *ExportUserCommentsRequest, opts ...grpc.CallOption) (*ExportUserCommentsResponse, error)
+	EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error)
+	ListMentionsForUser(ctx context.Context, in *ListMentionsForUserRequest, opts ...grpc.CallOption) (*ListMentionsForUserResponse, error)
+	PinComment(ctx context.Context, in *PinCommentRequest, opts ...grpc.CallOption) (*PinCommentResponse, error)
+	UnpinComment(ctx context.Context, in *UnpinCommentRequest, opts ...grpc.CallOption) (*UnpinCommentResponse, error)
+	HideComment(ctx context.Context, in *HideCommentRequest, opts ...grpc.CallOption) (*HideCommentResponse, error)
+	UnhideComment(ctx context.Context, in *UnhideCommentRequest, opts ...grpc.CallOption) (*UnhideCommentResponse, error)
+	BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*BlockUserResponse, error)
+	UnblockUser(ctx context.Context, in *UnblockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error)
+	ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error)
+	ShadowBanUser(ctx context.Context, in *ShadowBanUserRequest, opts ...grpc.CallOption) (*ShadowBanUserResponse, error)
+	LiftShadowBan(ctx context.Context, in *LiftShadowBanRequest, opts ...grpc.CallOption) (*LiftShadowBanResponse, error)
+	ListShadowBans(ctx context.Context, in *ListShadowBansRequest, opts ...grpc.CallOption) (*ListShadowBansResponse, error)
+	SetModerationMode(ctx context.Context, in *SetModerationModeRequest, opts ...grpc.CallOption) (*SetModerationModeResponse, error)
+	ListPendingComments(ctx context.Context, in *ListPendingCommentsRequest, opts ...grpc.CallOption) (*ListPendingCommentsResponse, error)
+	ApproveComment(ctx context.Context, in *ApproveCommentRequest, opts ...grpc.CallOption) (*ApproveCommentResponse, error)
+	RejectComment(ctx context.Context, in *RejectCommentRequest, opts ...grpc.CallOption) (*RejectCommentResponse, error)
+	ListDuplicateClusters(ctx context.Context, in *ListDuplicateClustersRequest, opts ...grpc.CallOption) (*ListDuplicateClustersResponse, error)
+	BulkDeleteComments(ctx context.Context, in *BulkDeleteCommentsRequest, opts ...grpc.CallOption) (*BulkDeleteCommentsResponse, error)
+	BulkHideComments(ctx context.Context, in *BulkHideCommentsRequest, opts ...grpc.CallOption) (*BulkHideCommentsResponse, error)
+	ImportComments(ctx context.Context, opts ...grpc.CallOption) (CommentService_ImportCommentsClient, error)
+	QueryAuditLog(ctx context.Context, in *QueryAuditLogRequest, opts ...grpc.CallOption) (*QueryAuditLogResponse, error)
+}
+
+type commentServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCommentServiceClient(cc grpc.ClientConnInterface) CommentServiceClient {
+	return &commentServiceClient{cc}
+}
+
+func (c *commentServiceClient) GetCommentByModID(ctx context.Context, in *GetCommentByModIDRequest, opts ...grpc.CallOption) (*GetCommentByModIDResponse, error) {
+	out := new(GetCommentByModIDResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/GetCommentByModID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) GetCommentsByModIDs(ctx context.Context, in *GetCommentsByModIDsRequest, opts ...grpc.CallOption) (*GetCommentsByModIDsResponse, error) {
+	out := new(GetCommentsByModIDsResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/GetCommentsByModIDs", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) GetCommentByID(ctx context.Context, in *GetCommentByIDRequest, opts ...grpc.CallOption) (*GetCommentByIDResponse, error) {
+	out := new(GetCommentByIDResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/GetCommentByID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) GetCommentsByUserID(ctx context.Context, in *GetCommentsByUserIDRequest, opts ...grpc.CallOption) (*GetCommentsByUserIDResponse, error) {
+	out := new(GetCommentsByUserIDResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/GetCommentsByUserID", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) UpdateComment(ctx context.Context, in *UpdateCommentRequest, opts ...grpc.CallOption) (*UpdateCommentResponse, error) {
+	out := new(UpdateCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/UpdateComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) DeleteComment(ctx context.Context, in *DeleteCommentRequest, opts ...grpc.CallOption) (*DeleteCommentResponse, error) {
+	out := new(DeleteCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/DeleteComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) CreateComment(ctx context.Context, in *CreateCommentRequest, opts ...grpc.CallOption) (*CreateCommentResponse, error) {
+	out := new(CreateCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/CreateComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ExportUserComments(ctx context.Context, in *ExportUserCommentsRequest, opts ...grpc.CallOption) (*ExportUserCommentsResponse, error) {
+	out := new(ExportUserCommentsResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ExportUserComments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) EraseUserData(ctx context.Context, in *EraseUserDataRequest, opts ...grpc.CallOption) (*EraseUserDataResponse, error) {
+	out := new(EraseUserDataResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/EraseUserData", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ListMentionsForUser(ctx context.Context, in *ListMentionsForUserRequest, opts ...grpc.CallOption) (*ListMentionsForUserResponse, error) {
+	out := new(ListMentionsForUserResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ListMentionsForUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) PinComment(ctx context.Context, in *PinCommentRequest, opts ...grpc.CallOption) (*PinCommentResponse, error) {
+	out := new(PinCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/PinComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) UnpinComment(ctx context.Context, in *UnpinCommentRequest, opts ...grpc.CallOption) (*UnpinCommentResponse, error) {
+	out := new(UnpinCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/UnpinComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) HideComment(ctx context.Context, in *HideCommentRequest, opts ...grpc.CallOption) (*HideCommentResponse, error) {
+	out := new(HideCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/HideComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) UnhideComment(ctx context.Context, in *UnhideCommentRequest, opts ...grpc.CallOption) (*UnhideCommentResponse, error) {
+	out := new(UnhideCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/UnhideComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) BlockUser(ctx context.Context, in *BlockUserRequest, opts ...grpc.CallOption) (*BlockUserResponse, error) {
+	out := new(BlockUserResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/BlockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) UnblockUser(ctx context.Context, in *UnblockUserRequest, opts ...grpc.CallOption) (*UnblockUserResponse, error) {
+	out := new(UnblockUserResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/UnblockUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ListBlocked(ctx context.Context, in *ListBlockedRequest, opts ...grpc.CallOption) (*ListBlockedResponse, error) {
+	out := new(ListBlockedResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ListBlocked", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ShadowBanUser(ctx context.Context, in *ShadowBanUserRequest, opts ...grpc.CallOption) (*ShadowBanUserResponse, error) {
+	out := new(ShadowBanUserResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ShadowBanUser", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) LiftShadowBan(ctx context.Context, in *LiftShadowBanRequest, opts ...grpc.CallOption) (*LiftShadowBanResponse, error) {
+	out := new(LiftShadowBanResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/LiftShadowBan", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ListShadowBans(ctx context.Context, in *ListShadowBansRequest, opts ...grpc.CallOption) (*ListShadowBansResponse, error) {
+	out := new(ListShadowBansResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ListShadowBans", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) SetModerationMode(ctx context.Context, in *SetModerationModeRequest, opts ...grpc.CallOption) (*SetModerationModeResponse, error) {
+	out := new(SetModerationModeResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/SetModerationMode", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ListPendingComments(ctx context.Context, in *ListPendingCommentsRequest, opts ...grpc.CallOption) (*ListPendingCommentsResponse, error) {
+	out := new(ListPendingCommentsResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ListPendingComments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ApproveComment(ctx context.Context, in *ApproveCommentRequest, opts ...grpc.CallOption) (*ApproveCommentResponse, error) {
+	out := new(ApproveCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ApproveComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) RejectComment(ctx context.Context, in *RejectCommentRequest, opts ...grpc.CallOption) (*RejectCommentResponse, error) {
+	out := new(RejectCommentResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/RejectComment", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ListDuplicateClusters(ctx context.Context, in *ListDuplicateClustersRequest, opts ...grpc.CallOption) (*ListDuplicateClustersResponse, error) {
+	out := new(ListDuplicateClustersResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/ListDuplicateClusters", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) BulkDeleteComments(ctx context.Context, in *BulkDeleteCommentsRequest, opts ...grpc.CallOption) (*BulkDeleteCommentsResponse, error) {
+	out := new(BulkDeleteCommentsResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/BulkDeleteComments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) BulkHideComments(ctx context.Context, in *BulkHideCommentsRequest, opts ...grpc.CallOption) (*BulkHideCommentsResponse, error) {
+	out := new(BulkHideCommentsResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/BulkHideComments", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *commentServiceClient) ImportComments(ctx context.Context, opts ...grpc.CallOption) (CommentService_ImportCommentsClient, error) {
+	stream, err := c.cc.NewStream(ctx, &CommentService_ServiceDesc.Streams[0], "/comment_service.CommentService/ImportComments", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &commentServiceImportCommentsClient{stream}
+	return x, nil
+}
+
+type CommentService_ImportCommentsClient interface {
+	Send(*ImportCommentsRequest) error
+	CloseAndRecv() (*ImportCommentsResponse, error)
+	grpc.ClientStream
+}
+
+type commentServiceImportCommentsClient struct {
+	grpc.ClientStream
+}
+
+func (x *commentServiceImportCommentsClient) Send(m *ImportCommentsRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *commentServiceImportCommentsClient) CloseAndRecv() (*ImportCommentsResponse, error) {
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	m := new(ImportCommentsResponse)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *commentServiceClient) QueryAuditLog(ctx context.Context, in *QueryAuditLogRequest, opts ...grpc.CallOption) (*QueryAuditLogResponse, error) {
+	out := new(QueryAuditLogResponse)
+	err := c.cc.Invoke(ctx, "/comment_service.CommentService/QueryAuditLog", in, out, opts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CommentServiceServer is the server API for CommentService service.
+// All implementations must embed UnimplementedCommentServiceServer
+// for forward compatibility
+type CommentServiceServer interface {
+	GetCommentByModID(context.Context, *GetCommentByModIDRequest) (*GetCommentByModIDResponse, error)
+	GetCommentsByModIDs(context.Context, *GetCommentsByModIDsRequest) (*GetCommentsByModIDsResponse, error)
+	GetCommentByID(context.Context, *GetCommentByIDRequest) (*GetCommentByIDResponse, error)
+	GetCommentsByUserID(context.Context, *GetCommentsByUserIDRequest) (*GetCommentsByUserIDResponse, error)
+	UpdateComment(context.Context, *UpdateCommentRequest) (*UpdateCommentResponse, error)
+	DeleteComment(context.Context, *DeleteCommentRequest) (*DeleteCommentResponse, error)
+	CreateComment(context.Context, *CreateCommentRequest) (*CreateCommentResponse, error)
+	ExportUserComments(context.Context, *ExportUserCommentsRequest) (*ExportUserCommentsResponse, error)
+	EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error)
+	ListMentionsForUser(context.Context, *ListMentionsForUserRequest) (*ListMentionsForUserResponse, error)
+	PinComment(context.Context, *PinCommentRequest) (*PinCommentResponse, error)
+	UnpinComment(context.Context, *UnpinCommentRequest) (*UnpinCommentResponse, error)
+	HideComment(context.Context, *HideCommentRequest) (*HideCommentResponse, error)
+	UnhideComment(context.Context, *UnhideCommentRequest) (*UnhideCommentResponse, error)
+	BlockUser(context.Context, *BlockUserRequest) (*BlockUserResponse, error)
+	UnblockUser(context.Context, *UnblockUserRequest) (*UnblockUserResponse, error)
+	ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error)
+	ShadowBanUser(context.Context, *ShadowBanUserRequest) (*ShadowBanUserResponse, error)
+	LiftShadowBan(context.Context, *LiftShadowBanRequest) (*LiftShadowBanResponse, error)
+	ListShadowBans(context.Context, *ListShadowBansRequest) (*ListShadowBansResponse, error)
+	SetModerationMode(context.Context, *SetModerationModeRequest) (*SetModerationModeResponse, error)
+	ListPendingComments(context.Context, *ListPendingCommentsRequest) (*ListPendingCommentsResponse, error)
+	ApproveComment(context.Context, *ApproveCommentRequest) (*ApproveCommentResponse, error)
+	RejectComment(context.Context, *RejectCommentRequest) (*RejectCommentResponse, error)
+	ListDuplicateClusters(context.Context, *ListDuplicateClustersRequest) (*ListDuplicateClustersResponse, error)
+	BulkDeleteComments(context.Context, *BulkDeleteCommentsRequest) (*BulkDeleteCommentsResponse, error)
+	BulkHideComments(context.Context, *BulkHideCommentsRequest) (*BulkHideCommentsResponse, error)
+	ImportComments(CommentService_ImportCommentsServer) error
+	QueryAuditLog(context.Context, *QueryAuditLogRequest) (*QueryAuditLogResponse, error)
+	mustEmbedUnimplementedCommentServiceServer()
+}
+
+// UnimplementedCommentServiceServer must be embedded to have forward compatible implementations.
+type UnimplementedCommentServiceServer struct {
+}
+
+func (UnimplementedCommentServiceServer) GetCommentByModID(context.Context, *GetCommentByModIDRequest) (*GetCommentByModIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCommentByModID not implemented")
+}
+func (UnimplementedCommentServiceServer) GetCommentsByModIDs(context.Context, *GetCommentsByModIDsRequest) (*GetCommentsByModIDsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCommentsByModIDs not implemented")
+}
+func (UnimplementedCommentServiceServer) GetCommentByID(context.Context, *GetCommentByIDRequest) (*GetCommentByIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCommentByID not implemented")
+}
+func (UnimplementedCommentServiceServer) GetCommentsByUserID(context.Context, *GetCommentsByUserIDRequest) (*GetCommentsByUserIDResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCommentsByUserID not implemented")
+}
+func (UnimplementedCommentServiceServer) UpdateComment(context.Context, *UpdateCommentRequest) (*UpdateCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UpdateComment not implemented")
+}
+func (UnimplementedCommentServiceServer) DeleteComment(context.Context, *DeleteCommentRequest) (*DeleteCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method DeleteComment not implemented")
+}
+func (UnimplementedCommentServiceServer) CreateComment(context.Context, *CreateCommentRequest) (*CreateCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CreateComment not implemented")
+}
+func (UnimplementedCommentServiceServer) ExportUserComments(context.Context, *ExportUserCommentsRequest) (*ExportUserCommentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ExportUserComments not implemented")
+}
+func (UnimplementedCommentServiceServer) EraseUserData(context.Context, *EraseUserDataRequest) (*EraseUserDataResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method EraseUserData not implemented")
+}
+func (UnimplementedCommentServiceServer) ListMentionsForUser(context.Context, *ListMentionsForUserRequest) (*ListMentionsForUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListMentionsForUser not implemented")
+}
+func (UnimplementedCommentServiceServer) PinComment(context.Context, *PinCommentRequest) (*PinCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PinComment not implemented")
+}
+func (UnimplementedCommentServiceServer) UnpinComment(context.Context, *UnpinCommentRequest) (*UnpinCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnpinComment not implemented")
+}
+func (UnimplementedCommentServiceServer) HideComment(context.Context, *HideCommentRequest) (*HideCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method HideComment not implemented")
+}
+func (UnimplementedCommentServiceServer) UnhideComment(context.Context, *UnhideCommentRequest) (*UnhideCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnhideComment not implemented")
+}
+func (UnimplementedCommentServiceServer) BlockUser(context.Context, *BlockUserRequest) (*BlockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BlockUser not implemented")
+}
+func (UnimplementedCommentServiceServer) UnblockUser(context.Context, *UnblockUserRequest) (*UnblockUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method UnblockUser not implemented")
+}
+func (UnimplementedCommentServiceServer) ListBlocked(context.Context, *ListBlockedRequest) (*ListBlockedResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListBlocked not implemented")
+}
+func (UnimplementedCommentServiceServer) ShadowBanUser(context.Context, *ShadowBanUserRequest) (*ShadowBanUserResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ShadowBanUser not implemented")
+}
+func (UnimplementedCommentServiceServer) LiftShadowBan(context.Context, *LiftShadowBanRequest) (*LiftShadowBanResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method LiftShadowBan not implemented")
+}
+func (UnimplementedCommentServiceServer) ListShadowBans(context.Context, *ListShadowBansRequest) (*ListShadowBansResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListShadowBans not implemented")
+}
+func (UnimplementedCommentServiceServer) SetModerationMode(context.Context, *SetModerationModeRequest) (*SetModerationModeResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetModerationMode not implemented")
+}
+func (UnimplementedCommentServiceServer) ListPendingComments(context.Context, *ListPendingCommentsRequest) (*ListPendingCommentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListPendingComments not implemented")
+}
+func (UnimplementedCommentServiceServer) ApproveComment(context.Context, *ApproveCommentRequest) (*ApproveCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ApproveComment not implemented")
+}
+func (UnimplementedCommentServiceServer) RejectComment(context.Context, *RejectCommentRequest) (*RejectCommentResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RejectComment not implemented")
+}
+func (UnimplementedCommentServiceServer) ListDuplicateClusters(context.Context, *ListDuplicateClustersRequest) (*ListDuplicateClustersResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListDuplicateClusters not implemented")
+}
+func (UnimplementedCommentServiceServer) BulkDeleteComments(context.Context, *BulkDeleteCommentsRequest) (*BulkDeleteCommentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BulkDeleteComments not implemented")
+}
+func (UnimplementedCommentServiceServer) BulkHideComments(context.Context, *BulkHideCommentsRequest) (*BulkHideCommentsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BulkHideComments not implemented")
+}
+func (UnimplementedCommentServiceServer) ImportComments(CommentService_ImportCommentsServer) error {
+	return status.Errorf(codes.Unimplemented, "method ImportComments not implemented")
+}
+func (UnimplementedCommentServiceServer) QueryAuditLog(context.Context, *QueryAuditLogRequest) (*QueryAuditLogResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method QueryAuditLog not implemented")
+}
+func (UnimplementedCommentServiceServer) mustEmbedUnimplementedCommentServiceServer() {}
+
+// UnsafeCommentServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CommentServiceServer will
+// result in compilation errors.
+type UnsafeCommentServiceServer interface {
+	mustEmbedUnimplementedCommentServiceServer()
+}
+
+func RegisterCommentServiceServer(s grpc.ServiceRegistrar, srv CommentServiceServer) {
+	s.RegisterService(&CommentService_ServiceDesc, srv)
+}
+
+func _CommentService_GetCommentByModID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommentByModIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).GetCommentByModID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/GetCommentByModID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).GetCommentByModID(ctx, req.(*GetCommentByModIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_GetCommentsByModIDs_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommentsByModIDsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).GetCommentsByModIDs(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/GetCommentsByModIDs",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).GetCommentsByModIDs(ctx, req.(*GetCommentsByModIDsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_GetCommentByID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommentByIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).GetCommentByID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/GetCommentByID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).GetCommentByID(ctx, req.(*GetCommentByIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_GetCommentsByUserID_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCommentsByUserIDRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).GetCommentsByUserID(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/GetCommentsByUserID",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).GetCommentsByUserID(ctx, req.(*GetCommentsByUserIDRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_UpdateComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UpdateCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).UpdateComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/UpdateComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).UpdateComment(ctx, req.(*UpdateCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_DeleteComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(DeleteCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).DeleteComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/DeleteComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).DeleteComment(ctx, req.(*DeleteCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_CreateComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CreateCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).CreateComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/CreateComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).CreateComment(ctx, req.(*CreateCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ExportUserComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ExportUserCommentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ExportUserComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ExportUserComments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ExportUserComments(ctx, req.(*ExportUserCommentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_EraseUserData_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(EraseUserDataRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).EraseUserData(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/EraseUserData",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).EraseUserData(ctx, req.(*EraseUserDataRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ListMentionsForUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListMentionsForUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListMentionsForUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ListMentionsForUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListMentionsForUser(ctx, req.(*ListMentionsForUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_PinComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(PinCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).PinComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/PinComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).PinComment(ctx, req.(*PinCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_UnpinComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnpinCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).UnpinComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/UnpinComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).UnpinComment(ctx, req.(*UnpinCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_HideComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(HideCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).HideComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/HideComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).HideComment(ctx, req.(*HideCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_UnhideComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnhideCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).UnhideComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/UnhideComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).UnhideComment(ctx, req.(*UnhideCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_BlockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BlockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).BlockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/BlockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).BlockUser(ctx, req.(*BlockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_UnblockUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(UnblockUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).UnblockUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/UnblockUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).UnblockUser(ctx, req.(*UnblockUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ListBlocked_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListBlockedRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListBlocked(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ListBlocked",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListBlocked(ctx, req.(*ListBlockedRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ShadowBanUser_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ShadowBanUserRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ShadowBanUser(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ShadowBanUser",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ShadowBanUser(ctx, req.(*ShadowBanUserRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_LiftShadowBan_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(LiftShadowBanRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).LiftShadowBan(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/LiftShadowBan",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).LiftShadowBan(ctx, req.(*LiftShadowBanRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ListShadowBans_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListShadowBansRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListShadowBans(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ListShadowBans",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListShadowBans(ctx, req.(*ListShadowBansRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_SetModerationMode_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(SetModerationModeRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).SetModerationMode(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/SetModerationMode",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).SetModerationMode(ctx, req.(*SetModerationModeRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ListPendingComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListPendingCommentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListPendingComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ListPendingComments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListPendingComments(ctx, req.(*ListPendingCommentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ApproveComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ApproveCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ApproveComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ApproveComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ApproveComment(ctx, req.(*ApproveCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_RejectComment_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RejectCommentRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).RejectComment(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/RejectComment",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).RejectComment(ctx, req.(*RejectCommentRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ListDuplicateClusters_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ListDuplicateClustersRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).ListDuplicateClusters(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/ListDuplicateClusters",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).ListDuplicateClusters(ctx, req.(*ListDuplicateClustersRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_BulkDeleteComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkDeleteCommentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).BulkDeleteComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/BulkDeleteComments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).BulkDeleteComments(ctx, req.(*BulkDeleteCommentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_BulkHideComments_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BulkHideCommentsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).BulkHideComments(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/BulkHideComments",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).BulkHideComments(ctx, req.(*BulkHideCommentsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CommentService_ImportComments_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(CommentServiceServer).ImportComments(&commentServiceImportCommentsServer{stream})
+}
+
+type CommentService_ImportCommentsServer interface {
+	SendAndClose(*ImportCommentsResponse) error
+	Recv() (*ImportCommentsRequest, error)
+	grpc.ServerStream
+}
+
+type commentServiceImportCommentsServer struct {
+	grpc.ServerStream
+}
+
+func (x *commentServiceImportCommentsServer) SendAndClose(m *ImportCommentsResponse) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *commentServiceImportCommentsServer) Recv() (*ImportCommentsRequest, error) {
+	m := new(ImportCommentsRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func _CommentService_QueryAuditLog_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(QueryAuditLogRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CommentServiceServer).QueryAuditLog(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: "/comment_service.CommentService/QueryAuditLog",
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CommentServiceServer).QueryAuditLog(ctx, req.(*QueryAuditLogRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CommentService_ServiceDesc is the grpc.ServiceDesc for CommentService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CommentService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "comment_service.CommentService",
+	HandlerType: (*CommentServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCommentByModID",
+			Handler:    _CommentService_GetCommentByModID_Handler,
+		},
+		{
+			MethodName: "GetCommentsByModIDs",
+			Handler:    _CommentService_GetCommentsByModIDs_Handler,
+		},
+		{
+			MethodName: "GetCommentByID",
+			Handler:    _CommentService_GetCommentByID_Handler,
+		},
+		{
+			MethodName: "GetCommentsByUserID",
+			Handler:    _CommentService_GetCommentsByUserID_Handler,
+		},
+		{
+			MethodName: "UpdateComment",
+			Handler:    _CommentService_UpdateComment_Handler,
+		},
+		{
+			MethodName: "DeleteComment",
+			Handler:    _CommentService_DeleteComment_Handler,
+		},
+		{
+			MethodName: "CreateComment",
+			Handler:    _CommentService_CreateComment_Handler,
+		},
+		{
+			MethodName: "ExportUserComments",
+			Handler:    _CommentService_ExportUserComments_Handler,
+		},
+		{
+			MethodName: "EraseUserData",
+			Handler:    _CommentService_EraseUserData_Handler,
+		},
+		{
+			MethodName: "ListMentionsForUser",
+			Handler:    _CommentService_ListMentionsForUser_Handler,
+		},
+		{
+			MethodName: "PinComment",
+			Handler:    _CommentService_PinComment_Handler,
+		},
+		{
+			MethodName: "UnpinComment",
+			Handler:    _CommentService_UnpinComment_Handler,
+		},
+		{
+			MethodName: "HideComment",
+			Handler:    _CommentService_HideComment_Handler,
+		},
+		{
+			MethodName: "UnhideComment",
+			Handler:    _CommentService_UnhideComment_Handler,
+		},
+		{
+			MethodName: "BlockUser",
+			Handler:    _CommentService_BlockUser_Handler,
+		},
+		{
+			MethodName: "UnblockUser",
+			Handler:    _CommentService_UnblockUser_Handler,
+		},
+		{
+			MethodName: "ListBlocked",
+			Handler:    _CommentService_ListBlocked_Handler,
+		},
+		{
+			MethodName: "ShadowBanUser",
+			Handler:    _CommentService_ShadowBanUser_Handler,
+		},
+		{
+			MethodName: "LiftShadowBan",
+			Handler:    _CommentService_LiftShadowBan_Handler,
+		},
+		{
+			MethodName: "ListShadowBans",
+			Handler:    _CommentService_ListShadowBans_Handler,
+		},
+		{
+			MethodName: "SetModerationMode",
+			Handler:    _CommentService_SetModerationMode_Handler,
+		},
+		{
+			MethodName: "ListPendingComments",
+			Handler:    _CommentService_ListPendingComments_Handler,
+		},
+		{
+			MethodName: "ApproveComment",
+			Handler:    _CommentService_ApproveComment_Handler,
+		},
+		{
+			MethodName: "RejectComment",
+			Handler:    _CommentService_RejectComment_Handler,
+		},
+		{
+			MethodName: "ListDuplicateClusters",
+			Handler:    _CommentService_ListDuplicateClusters_Handler,
+		},
+		{
+			MethodName: "BulkDeleteComments",
+			Handler:    _CommentService_BulkDeleteComments_Handler,
+		},
+		{
+			MethodName: "BulkHideComments",
+			Handler:    _CommentService_BulkHideComments_Handler,
+		},
+		{
+			MethodName: "QueryAuditLog",
+			Handler:    _CommentService_QueryAuditLog_Handler,
+		},
+	},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "ImportComments",
+			Handler:       _CommentService_ImportComments_Handler,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "protobuf/comment/comment.proto",
+}
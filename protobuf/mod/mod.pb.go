@@ -0,0 +1,347 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.28.1
+// 	protoc        v3.21.7
+// source: protobuf/mod/mod.proto
+
+package mod
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Mod struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID                string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Name              string                 `protobuf:"bytes,2,opt,name=Name,proto3" json:"Name,omitempty"`
+	Description       string                 `protobuf:"bytes,3,opt,name=Description,proto3" json:"Description,omitempty"`
+	ModTypeCategoryID string                 `protobuf:"bytes,4,opt,name=ModTypeCategoryID,proto3" json:"ModTypeCategoryID,omitempty"`
+	ReleaseYear       int32                  `protobuf:"varint,5,opt,name=ReleaseYear,proto3" json:"ReleaseYear,omitempty"`
+	CreateAt          *timestamppb.Timestamp `protobuf:"bytes,9,opt,name=Create_at,json=CreateAt,proto3" json:"Create_at,omitempty"`
+	UserID            string                 `protobuf:"bytes,10,opt,name=UserID,proto3" json:"UserID,omitempty"`
+}
+
+func (x *Mod) Reset() {
+	*x = Mod{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_mod_mod_proto_msgTypes[0]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *Mod) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Mod) ProtoMessage() {}
+
+func (x *Mod) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_mod_mod_proto_msgTypes[0]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Mod.ProtoReflect.Descriptor instead.
+func (*Mod) Descriptor() ([]byte, []int) {
+	return file_protobuf_mod_mod_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Mod) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+func (x *Mod) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Mod) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+func (x *Mod) GetModTypeCategoryID() string {
+	if x != nil {
+		return x.ModTypeCategoryID
+	}
+	return ""
+}
+
+func (x *Mod) GetReleaseYear() int32 {
+	if x != nil {
+		return x.ReleaseYear
+	}
+	return 0
+}
+
+func (x *Mod) GetCreateAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreateAt
+	}
+	return nil
+}
+
+func (x *Mod) GetUserID() string {
+	if x != nil {
+		return x.UserID
+	}
+	return ""
+}
+
+// GetModByID
+type GetModByIDRequest struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	ID string `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+}
+
+func (x *GetModByIDRequest) Reset() {
+	*x = GetModByIDRequest{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_mod_mod_proto_msgTypes[1]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetModByIDRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModByIDRequest) ProtoMessage() {}
+
+func (x *GetModByIDRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_mod_mod_proto_msgTypes[1]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModByIDRequest.ProtoReflect.Descriptor instead.
+func (*GetModByIDRequest) Descriptor() ([]byte, []int) {
+	return file_protobuf_mod_mod_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetModByIDRequest) GetID() string {
+	if x != nil {
+		return x.ID
+	}
+	return ""
+}
+
+type GetModByIDResponse struct {
+	state         protoimpl.MessageState
+	sizeCache     protoimpl.SizeCache
+	unknownFields protoimpl.UnknownFields
+
+	Mod *Mod `protobuf:"bytes,1,opt,name=Mod,proto3" json:"Mod,omitempty"`
+}
+
+func (x *GetModByIDResponse) Reset() {
+	*x = GetModByIDResponse{}
+	if protoimpl.UnsafeEnabled {
+		mi := &file_protobuf_mod_mod_proto_msgTypes[2]
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		ms.StoreMessageInfo(mi)
+	}
+}
+
+func (x *GetModByIDResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetModByIDResponse) ProtoMessage() {}
+
+func (x *GetModByIDResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_protobuf_mod_mod_proto_msgTypes[2]
+	if protoimpl.UnsafeEnabled && x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetModByIDResponse.ProtoReflect.Descriptor instead.
+func (*GetModByIDResponse) Descriptor() ([]byte, []int) {
+	return file_protobuf_mod_mod_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetModByIDResponse) GetMod() *Mod {
+	if x != nil {
+		return x.Mod
+	}
+	return nil
+}
+
+var File_protobuf_mod_mod_proto protoreflect.FileDescriptor
+
+var file_protobuf_mod_mod_proto_rawDesc = []byte{
+	0x0a, 0x16, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x6d, 0x6f, 0x64, 0x2f, 0x6d,
+	0x6f, 0x64, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x12, 0x0b, 0x6d, 0x6f, 0x64, 0x5f, 0x73, 0x65,
+	0x72, 0x76, 0x69, 0x63, 0x65, 0x1a, 0x1f, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72,
+	0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22, 0xec, 0x01, 0x0a, 0x03, 0x4d, 0x6f, 0x64, 0x12, 0x0e,
+	0x0a, 0x02, 0x49, 0x44, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x12, 0x12,
+	0x0a, 0x04, 0x4e, 0x61, 0x6d, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x4e, 0x61,
+	0x6d, 0x65, 0x12, 0x20, 0x0a, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70, 0x74, 0x69, 0x6f,
+	0x6e, 0x18, 0x03, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0b, 0x44, 0x65, 0x73, 0x63, 0x72, 0x69, 0x70,
+	0x74, 0x69, 0x6f, 0x6e, 0x12, 0x2c, 0x0a, 0x11, 0x4d, 0x6f, 0x64, 0x54, 0x79, 0x70, 0x65, 0x43,
+	0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79, 0x49, 0x44, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x11, 0x4d, 0x6f, 0x64, 0x54, 0x79, 0x70, 0x65, 0x43, 0x61, 0x74, 0x65, 0x67, 0x6f, 0x72, 0x79,
+	0x49, 0x44, 0x12, 0x20, 0x0a, 0x0b, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65, 0x59, 0x65, 0x61,
+	0x72, 0x18, 0x05, 0x20, 0x01, 0x28, 0x05, 0x52, 0x0b, 0x52, 0x65, 0x6c, 0x65, 0x61, 0x73, 0x65,
+	0x59, 0x65, 0x61, 0x72, 0x12, 0x37, 0x0a, 0x09, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x5f, 0x61,
+	0x74, 0x18, 0x09, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65,
+	0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54, 0x69, 0x6d, 0x65, 0x73, 0x74,
+	0x61, 0x6d, 0x70, 0x52, 0x08, 0x43, 0x72, 0x65, 0x61, 0x74, 0x65, 0x41, 0x74, 0x12, 0x16, 0x0a,
+	0x06, 0x55, 0x73, 0x65, 0x72, 0x49, 0x44, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x06, 0x55,
+	0x73, 0x65, 0x72, 0x49, 0x44, 0x22, 0x23, 0x0a, 0x11, 0x47, 0x65, 0x74, 0x4d, 0x6f, 0x64, 0x42,
+	0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x0e, 0x0a, 0x02, 0x49, 0x44,
+	0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x02, 0x49, 0x44, 0x22, 0x38, 0x0a, 0x12, 0x47, 0x65,
+	0x74, 0x4d, 0x6f, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x12, 0x22, 0x0a, 0x03, 0x4d, 0x6f, 0x64, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x10, 0x2e,
+	0x6d, 0x6f, 0x64, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x4d, 0x6f, 0x64, 0x52,
+	0x03, 0x4d, 0x6f, 0x64, 0x32, 0x5b, 0x0a, 0x0a, 0x4d, 0x6f, 0x64, 0x53, 0x65, 0x72, 0x76, 0x69,
+	0x63, 0x65, 0x12, 0x4d, 0x0a, 0x0a, 0x47, 0x65, 0x74, 0x4d, 0x6f, 0x64, 0x42, 0x79, 0x49, 0x44,
+	0x12, 0x1e, 0x2e, 0x6d, 0x6f, 0x64, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x47,
+	0x65, 0x74, 0x4d, 0x6f, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74,
+	0x1a, 0x1f, 0x2e, 0x6d, 0x6f, 0x64, 0x5f, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x47,
+	0x65, 0x74, 0x4d, 0x6f, 0x64, 0x42, 0x79, 0x49, 0x44, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x42, 0x3f, 0x5a, 0x3d, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f,
+	0x6d, 0x78, 0x62, 0x69, 0x6b, 0x65, 0x73, 0x2f, 0x6d, 0x78, 0x62, 0x69, 0x6b, 0x65, 0x73, 0x63,
+	0x6c, 0x69, 0x65, 0x6e, 0x74, 0x2e, 0x73, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x2e, 0x63, 0x6f,
+	0x6d, 0x6d, 0x65, 0x6e, 0x74, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f, 0x6d,
+	0x6f, 0x64, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x33,
+}
+
+var (
+	file_protobuf_mod_mod_proto_rawDescOnce sync.Once
+	file_protobuf_mod_mod_proto_rawDescData = file_protobuf_mod_mod_proto_rawDesc
+)
+
+func file_protobuf_mod_mod_proto_rawDescGZIP() []byte {
+	file_protobuf_mod_mod_proto_rawDescOnce.Do(func() {
+		file_protobuf_mod_mod_proto_rawDescData = protoimpl.X.CompressGZIP(file_protobuf_mod_mod_proto_rawDescData)
+	})
+	return file_protobuf_mod_mod_proto_rawDescData
+}
+
+var file_protobuf_mod_mod_proto_msgTypes = make([]protoimpl.MessageInfo, 3)
+var file_protobuf_mod_mod_proto_goTypes = []interface{}{
+	(*Mod)(nil),                   // 0: mod_service.Mod
+	(*GetModByIDRequest)(nil),     // 1: mod_service.GetModByIDRequest
+	(*GetModByIDResponse)(nil),    // 2: mod_service.GetModByIDResponse
+	(*timestamppb.Timestamp)(nil), // 3: google.protobuf.Timestamp
+}
+var file_protobuf_mod_mod_proto_depIdxs = []int32{
+	3, // 0: mod_service.Mod.Create_at:type_name -> google.protobuf.Timestamp
+	0, // 1: mod_service.GetModByIDResponse.Mod:type_name -> mod_service.Mod
+	1, // 2: mod_service.ModService.GetModByID:input_type -> mod_service.GetModByIDRequest
+	2, // 3: mod_service.ModService.GetModByID:output_type -> mod_service.GetModByIDResponse
+	3, // [3:4] is the sub-list for method output_type
+	2, // [2:3] is the sub-list for method input_type
+	2, // [2:2] is the sub-list for extension type_name
+	2, // [2:2] is the sub-list for extension extendee
+	0, // [0:2] is the sub-list for field type_name
+}
+
+func init() { file_protobuf_mod_mod_proto_init() }
+func file_protobuf_mod_mod_proto_init() {
+	if File_protobuf_mod_mod_proto != nil {
+		return
+	}
+	if !protoimpl.UnsafeEnabled {
+		file_protobuf_mod_mod_proto_msgTypes[0].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*Mod); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_mod_mod_proto_msgTypes[1].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetModByIDRequest); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+		file_protobuf_mod_mod_proto_msgTypes[2].Exporter = func(v interface{}, i int) interface{} {
+			switch v := v.(*GetModByIDResponse); i {
+			case 0:
+				return &v.state
+			case 1:
+				return &v.sizeCache
+			case 2:
+				return &v.unknownFields
+			default:
+				return nil
+			}
+		}
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_protobuf_mod_mod_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   3,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_protobuf_mod_mod_proto_goTypes,
+		DependencyIndexes: file_protobuf_mod_mod_proto_depIdxs,
+		MessageInfos:      file_protobuf_mod_mod_proto_msgTypes,
+	}.Build()
+	File_protobuf_mod_mod_proto = out.File
+	file_protobuf_mod_mod_proto_rawDesc = nil
+	file_protobuf_mod_mod_proto_goTypes = nil
+	file_protobuf_mod_mod_proto_depIdxs = nil
+}
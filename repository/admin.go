@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Undoes the deletion of a comment
+func (p *postgresRepository) Restore(ctx context.Context, id string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	result := db.Unscoped().Model(&models.Comment{}).Where(`id = ? AND deleted_at IS NOT NULL`, id).Update("deleted_at", nil)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Hard deletes the comments deleted before a time, in batches. Their mentions, pins and reviews go with them.
+func (p *postgresRepository) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for {
+		db, cancel := p.session(ctx)
+		deleted := db.Unscoped().Model(&models.Comment{}).Select(`id`).Where(`deleted_at < ?`, before).Limit(batchSize)
+		result := db.Unscoped().Where(`id IN (?)`, deleted).Delete(&models.Comment{})
+		cancel()
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < batchSize {
+			return purged, nil
+		}
+	}
+}
+
+func (p *postgresRepository) CommentStats(ctx context.Context) (*models.CommentStats, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var stats models.CommentStats
+	err := db.Unscoped().Model(&models.Comment{}).Select(`
+		count(*) FILTER (WHERE deleted_at IS NULL) AS total,
+		count(*) FILTER (WHERE deleted_at IS NULL AND status = ?) AS published,
+		count(*) FILTER (WHERE deleted_at IS NULL AND status = ?) AS pending,
+		count(*) FILTER (WHERE deleted_at IS NULL AND status = ?) AS rejected,
+		count(*) FILTER (WHERE deleted_at IS NULL AND hidden) AS hidden,
+		count(*) FILTER (WHERE deleted_at IS NOT NULL) AS deleted,
+		count(DISTINCT mod_id) FILTER (WHERE deleted_at IS NULL) AS mods,
+		count(DISTINCT user_id) FILTER (WHERE deleted_at IS NULL) AS authors,
+		count(*) FILTER (WHERE deleted_at IS NULL AND created_at >= ?) AS last_day`,
+		models.StatusPublished, models.StatusPending, models.StatusRejected, time.Now().Add(-24*time.Hour)).
+		Scan(&stats).Error
+	return &stats, err
+}
+
+// Passes every comment a filter selects to fn, a batch at a time in creation order. Each batch
+// has its own query timeout, so streams are not limited by it.
+func (p *postgresRepository) StreamComments(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error {
+	var after time.Time
+	var afterID string
+	for {
+		batch, err := p.streamBatch(ctx, filter, after, afterID)
+		if err != nil || len(batch) == 0 {
+			return err
+		}
+		if err := fn(batch); err != nil {
+			return err
+		}
+		last := batch[len(batch)-1]
+		after, afterID = last.CreatedAt, last.ID
+	}
+}
+
+// Returns the batch of comments created after a position, ordered by creation time and id
+func (p *postgresRepository) streamBatch(ctx context.Context, filter models.CommentFilter, after time.Time, afterID string) ([]*models.Comment, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Scopes(filterScope(filter))
+	if afterID != "" {
+		query = query.Where(`(created_at, id) > (?, ?)`, after, afterID)
+	}
+	var batch []*models.Comment
+	err := query.Order(`created_at, id`).Limit(batchSize).Find(&batch).Error
+	return batch, err
+}
+
+// Inserts imported comments with their ids and timestamps in one multi-row statement. Returns the ids that
+// existed, those comments are left alone or overwritten with a new version.
+func (p *postgresRepository) ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var existing []string
+	err := db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Unscoped().Model(&models.Comment{}).Where(`id IN ?`, commentIDs(comments)).Pluck("id", &existing).Error; err != nil {
+			return err
+		}
+		if overwrite {
+			return tx.Table(`comments`).Clauses(clause.OnConflict{
+				Columns: []clause.Column{{Name: "id"}},
+				DoUpdates: append(clause.AssignmentColumns([]string{"mod_id", "user_id", "text", "status", "hidden", "created_at", "updated_at", "deleted_at"}),
+					clause.Assignment{Column: clause.Column{Name: "version"}, Value: gorm.Expr(`"comments"."version" + 1`)}),
+			}).Create(importRows(comments)).Error
+		}
+
+		skip := make(map[string]bool, len(existing))
+		for _, id := range existing {
+			skip[id] = true
+		}
+		var created []*models.Comment
+		for _, comment := range comments {
+			if !skip[comment.ID] {
+				created = append(created, comment)
+			}
+		}
+		if len(created) == 0 {
+			return nil
+		}
+		return tx.Table(`comments`).Create(importRows(created)).Error
+	})
+	return existing, err
+}
+
+// Returns the columns of imported comments. Maps are inserted with their columns in a stable order,
+// while gorm orders columns that have a database default by map iteration when inserting structs.
+func importRows(comments []*models.Comment) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(comments))
+	for _, comment := range comments {
+		rows = append(rows, map[string]interface{}{
+			"id":         comment.ID,
+			"mod_id":     comment.ModID,
+			"user_id":    comment.UserID,
+			"text":       comment.Text,
+			"status":     comment.Status,
+			"hidden":     comment.Hidden,
+			"spam_score": comment.SpamScore,
+			"created_at": comment.CreatedAt,
+			"updated_at": comment.UpdatedAt,
+			"deleted_at": comment.DeletedAt,
+		})
+	}
+	return rows
+}
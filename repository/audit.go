@@ -0,0 +1,169 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+)
+
+// Advisory lock that serialises appends to the audit log, so every entry chains to the one before it
+const auditLockKey = 0x61756469
+
+// Statements that make the audit log append-only. Entries written before the hash chain may be chained once.
+var auditAppendOnly = []string{
+	`CREATE OR REPLACE FUNCTION audit_logs_append_only() RETURNS trigger AS $$
+	BEGIN
+		IF TG_OP = 'UPDATE' THEN
+			IF OLD.hash = '' THEN
+				RETURN NEW;
+			END IF;
+		END IF;
+		RAISE EXCEPTION 'audit_logs is append-only';
+	END $$ LANGUAGE plpgsql`,
+	`DROP TRIGGER IF EXISTS audit_logs_append_only ON audit_logs`,
+	`CREATE TRIGGER audit_logs_append_only BEFORE UPDATE OR DELETE ON audit_logs FOR EACH ROW EXECUTE FUNCTION audit_logs_append_only()`,
+	`DROP TRIGGER IF EXISTS audit_logs_no_truncate ON audit_logs`,
+	`CREATE TRIGGER audit_logs_no_truncate BEFORE TRUNCATE ON audit_logs FOR EACH STATEMENT EXECUTE FUNCTION audit_logs_append_only()`,
+}
+
+// Appends entries to the audit log in one transaction, each chained to the entry before it
+func (p *postgresRepository) Audit(ctx context.Context, entries ...*models.AuditLog) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		last, err := lockAuditChain(tx)
+		if err != nil {
+			return err
+		}
+		// Postgres keeps microseconds, the hash has to match the stored time
+		now := time.Now().UTC().Truncate(time.Microsecond)
+		for _, entry := range entries {
+			entry.Sequence = last.Sequence + 1
+			entry.PrevHash = last.Hash
+			entry.CreatedAt = now
+			entry.Hash = entry.ComputeHash()
+			if err := tx.Create(entry).Error; err != nil {
+				return err
+			}
+			last = entry
+		}
+		return nil
+	})
+}
+
+// Takes the audit lock for the rest of the transaction and returns the last chained entry
+func lockAuditChain(tx *gorm.DB) (*models.AuditLog, error) {
+	if err := tx.Exec(`SELECT pg_advisory_xact_lock(?)`, auditLockKey).Error; err != nil {
+		return nil, err
+	}
+	var last models.AuditLog
+	err := tx.Select(`sequence`, `hash`).Where(`hash <> ''`).Order(`sequence DESC`).Limit(1).Find(&last).Error
+	return &last, err
+}
+
+// Returns audit entries, newest first
+func (p *postgresRepository) SearchAuditLog(ctx context.Context, filter models.AuditFilter, pagination models.Pagination) ([]*models.AuditLog, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.AuditLog{})
+	if filter.Actor != "" {
+		query = query.Where(`actor = ?`, filter.Actor)
+	}
+	if filter.Action != "" {
+		query = query.Where(`action = ?`, filter.Action)
+	}
+	if filter.Target != "" {
+		query = query.Where(`target = ?`, filter.Target)
+	}
+	if filter.RequestID != "" {
+		query = query.Where(`request_id = ?`, filter.RequestID)
+	}
+	if !filter.Since.IsZero() {
+		query = query.Where(`created_at >= ?`, filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		query = query.Where(`created_at < ?`, filter.Until)
+	}
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+	var l []*models.AuditLog
+	err := query.Order(`sequence DESC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	return l, count, err
+}
+
+// Walks the audit log in order and reports the first entry that is missing, changed or out of the chain.
+// Removing the newest entries leaves a valid chain, compare LastHash with a copy kept elsewhere to notice.
+func (p *postgresRepository) VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error) {
+	result := &models.AuditVerification{}
+	var sequence int64
+	for {
+		batch, err := p.auditBatch(ctx, sequence)
+		if err != nil || len(batch) == 0 {
+			return result, err
+		}
+		for _, entry := range batch {
+			switch {
+			case entry.Sequence == sequence+2:
+				result.Reason = fmt.Sprintf("entry %d is missing", sequence+1)
+			case entry.Sequence != sequence+1:
+				result.Reason = fmt.Sprintf("entries %d-%d are missing", sequence+1, entry.Sequence-1)
+			case entry.PrevHash != result.LastHash:
+				result.Reason = "previous hash does not match"
+			case entry.Hash != entry.ComputeHash():
+				result.Reason = "hash does not match the entry"
+			}
+			if result.Reason != "" {
+				result.BrokenAt = sequence + 1
+				return result, nil
+			}
+			sequence = entry.Sequence
+			result.Entries++
+			result.LastHash = entry.Hash
+		}
+	}
+}
+
+// Returns the batch of audit entries after a sequence
+func (p *postgresRepository) auditBatch(ctx context.Context, after int64) ([]*models.AuditLog, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var batch []*models.AuditLog
+	err := db.Where(`sequence > ?`, after).Order(`sequence`).Limit(batchSize).Find(&batch).Error
+	return batch, err
+}
+
+// Chains the audit entries written before the hash chain, oldest first
+func migrateAuditChain(db *gorm.DB) error {
+	return db.Transaction(func(tx *gorm.DB) error {
+		last, err := lockAuditChain(tx)
+		if err != nil {
+			return err
+		}
+		var legacy []*models.AuditLog
+		err = tx.Select(`id`, `actor`, `action`, `target`, `detail`, `created_at`).Where(`hash = ''`).Order(`created_at, id`).Find(&legacy).Error
+		if err != nil {
+			return err
+		}
+		for _, entry := range legacy {
+			entry.Sequence = last.Sequence + 1
+			entry.PrevHash = last.Hash
+			entry.Hash = entry.ComputeHash()
+			err := tx.Model(&models.AuditLog{}).Where(`id = ?`, entry.ID).
+				Updates(map[string]interface{}{"sequence": entry.Sequence, "prev_hash": entry.PrevHash, "hash": entry.Hash}).Error
+			if err != nil {
+				return err
+			}
+			last = entry
+		}
+		return nil
+	})
+}
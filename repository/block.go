@@ -0,0 +1,52 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stores a block, blocking an already blocked user is a no-op
+func (p *postgresRepository) Block(ctx context.Context, blockerID string, blockedID string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.Block{BlockerID: blockerID, BlockedID: blockedID}).Error
+}
+
+func (p *postgresRepository) Unblock(ctx context.Context, blockerID string, blockedID string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Where(`blocker_id = ? AND blocked_id = ?`, blockerID, blockedID).Delete(&models.Block{}).Error
+}
+
+// Returns a page of the users blocked by a user, newest first
+func (p *postgresRepository) SearchBlocked(ctx context.Context, blockerID string, pagination models.Pagination) ([]*models.Block, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Block{}).Where(`blocker_id = ?`, blockerID).Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var l []*models.Block
+	err := query.Order(`created_at DESC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	return l, count, err
+}
+
+// Returns the users among userIDs that have blocked blockedID
+func (p *postgresRepository) SearchBlockers(ctx context.Context, blockedID string, userIDs []string) ([]string, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var blockers []string
+	if len(userIDs) == 0 {
+		return blockers, nil
+	}
+	err := db.Model(&models.Block{}).Where(`blocked_id = ? AND blocker_id IN ?`, blockedID, userIDs).Pluck("blocker_id", &blockers).Error
+	return blockers, err
+}
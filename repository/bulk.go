@@ -0,0 +1,131 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+)
+
+// SQLSTATE of a pattern Postgres can not compile
+const invalidRegularExpression = "2201B"
+
+// Apply a bulk moderation filter to a comment query
+func filterScope(filter models.CommentFilter) func(db *gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if filter.UserID != "" {
+			db = db.Where(`user_id = ?`, filter.UserID)
+		}
+		if filter.ModID != "" {
+			db = db.Where(`mod_id = ?`, filter.ModID)
+		}
+		if !filter.Since.IsZero() {
+			db = db.Where(`created_at >= ?`, filter.Since)
+		}
+		if !filter.Until.IsZero() {
+			db = db.Where(`created_at < ?`, filter.Until)
+		}
+		if filter.TextPattern != "" {
+			db = db.Where(`text ~* ?`, filter.TextPattern)
+		}
+		if filter.IncludeDeleted {
+			db = db.Unscoped()
+		}
+		return db
+	}
+}
+
+// Returns the comments with the given ids, unknown ids are left out
+func (p *postgresRepository) FindByIDs(ctx context.Context, ids []string) ([]*models.Comment, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var l []*models.Comment
+	err := db.Where(`id IN ?`, ids).Find(&l).Error
+	return l, err
+}
+
+// Returns at most limit comments a filter selects, oldest first
+func (p *postgresRepository) SearchByFilter(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var l []*models.Comment
+	err := db.Scopes(filterScope(filter)).Order(`created_at ASC`).Limit(limit).Find(&l).Error
+	return l, err
+}
+
+// Reports whether Postgres accepts a text pattern, the pattern is matched by Postgres and not by Go
+func (p *postgresRepository) ValidTextPattern(ctx context.Context, pattern string) (bool, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var matched bool
+	err := db.Raw(`SELECT '' ~* ?`, pattern).Scan(&matched).Error
+	var state interface{ SQLState() string }
+	if errors.As(err, &state) && state.SQLState() == invalidRegularExpression {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Returns the number of comments a filter selects
+func (p *postgresRepository) CountByFilter(ctx context.Context, filter models.CommentFilter) (int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&models.Comment{}).Scopes(filterScope(filter)).Count(&count).Error
+	return count, err
+}
+
+// Deletes comments in one transaction, pinned comments lose their pin
+func (p *postgresRepository) DeleteComments(ctx context.Context, comments []*models.Comment) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	ids := commentIDs(comments)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where(`id IN ?`, ids).Delete(&models.Comment{}).Error; err != nil {
+			return err
+		}
+		return unpinAll(tx, ids)
+	})
+}
+
+// Hides comments in one transaction, pinned comments lose their pin
+func (p *postgresRepository) HideComments(ctx context.Context, comments []*models.Comment) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	ids := commentIDs(comments)
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Comment{}).Where(`id IN ?`, ids).Update("hidden", true).Error; err != nil {
+			return err
+		}
+		return unpinAll(tx, ids)
+	})
+}
+
+// Unpins those of the comments that are pinned
+func unpinAll(tx *gorm.DB, ids []string) error {
+	var pinned []string
+	if err := tx.Model(&models.Pin{}).Where(`comment_id IN ?`, ids).Pluck("comment_id", &pinned).Error; err != nil {
+		return err
+	}
+	for _, id := range pinned {
+		if err := unpin(tx, id); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func commentIDs(comments []*models.Comment) []string {
+	ids := make([]string, 0, len(comments))
+	for _, comment := range comments {
+		ids = append(ids, comment.ID)
+	}
+	return ids
+}
@@ -0,0 +1,298 @@
+package repository
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"golang.org/x/sync/singleflight"
+)
+
+// Cache stores comment listings grouped by mod, so every listing of a mod can be dropped at once.
+// The in-process LRU is the default, a shared cache such as Redis can implement the same interface.
+type Cache interface {
+	Get(ctx context.Context, modID string, key string) ([]*models.Comment, bool)
+	Set(ctx context.Context, modID string, key string, comments []*models.Comment)
+	// Drops every listing of a mod
+	Invalidate(ctx context.Context, modID string)
+	// Drops every listing
+	Purge(ctx context.Context)
+}
+
+// Counters of a cached repository
+type CacheStats struct {
+	Hits      uint64
+	Misses    uint64
+	Coalesced uint64
+}
+
+// Share of the listings served from the cache
+func (s CacheStats) HitRate() float64 {
+	if s.Hits+s.Misses == 0 {
+		return 0
+	}
+	return float64(s.Hits) / float64(s.Hits+s.Misses)
+}
+
+// Repository that caches the anonymous listings of SearchByModID, the listings popular mods
+// serve the most. Listings of signed in viewers depend on their blocks and pending comments
+// and always go to the wrapped repository.
+type cachedRepository struct {
+	ModRepository
+	cache     Cache
+	loads     singleflight.Group
+	mu        sync.Mutex
+	epoch     uint64
+	versions  map[string]uint64
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	coalesced atomic.Uint64
+}
+
+func NewCachedRepository(repository ModRepository, cache Cache) *cachedRepository {
+	return &cachedRepository{ModRepository: repository, cache: cache, versions: map[string]uint64{}}
+}
+
+func (r *cachedRepository) Stats() CacheStats {
+	return CacheStats{Hits: r.hits.Load(), Misses: r.misses.Load(), Coalesced: r.coalesced.Load()}
+}
+
+func (r *cachedRepository) SearchByModID(ctx context.Context, modID string, options ListOptions) ([]*models.Comment, error) {
+	// Reads within a transaction see its uncommitted writes, they are neither cached nor shared
+	if options.Viewer.UserID != "" || ctx.Value(pendingKey{}) != nil {
+		return r.ModRepository.SearchByModID(ctx, modID, options)
+	}
+
+	key := cacheKey(options)
+	if comments, ok := r.cache.Get(ctx, modID, key); ok {
+		r.hits.Add(1)
+		return cloneComments(comments), nil
+	}
+	r.misses.Add(1)
+
+	// A load only fills the cache when no write invalidated the mod meanwhile, and requests
+	// after an invalidation never join a load that started before it. The load is shared, so it
+	// outlives the caller that started it and is only limited by the query timeout.
+	version := r.version(modID)
+	result, err, shared := r.loads.Do(fmt.Sprintf("%s/%d/%d/%s", modID, version.epoch, version.mod, key), func() (interface{}, error) {
+		ctx := detachedContext{parent: ctx}
+		comments, err := r.ModRepository.SearchByModID(ctx, modID, options)
+		if err != nil {
+			return nil, err
+		}
+		if r.version(modID) == version {
+			r.cache.Set(ctx, modID, key, comments)
+		}
+		return comments, nil
+	})
+	if shared {
+		r.coalesced.Add(1)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return cloneComments(result.([]*models.Comment)), nil
+}
+
+// Listings read by others before a transaction commits may be cached again, so the invalidations
+// of a transaction are repeated once it ends
+func (r *cachedRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(pendingKey{}) != nil {
+		return r.ModRepository.Transaction(ctx, fn)
+	}
+	pending := &pendingInvalidations{mods: map[string]bool{}}
+	defer r.flush(ctx, pending)
+	return r.ModRepository.Transaction(context.WithValue(ctx, pendingKey{}, pending), fn)
+}
+
+func (r *cachedRepository) Create(ctx context.Context, comment *models.Comment) error {
+	defer r.invalidate(ctx, comment.ModID)
+	return r.ModRepository.Create(ctx, comment)
+}
+
+func (r *cachedRepository) Update(ctx context.Context, comment *models.Comment, changes map[string]interface{}) error {
+	defer r.invalidate(ctx, comment.ModID)
+	return r.ModRepository.Update(ctx, comment, changes)
+}
+
+func (r *cachedRepository) Delete(ctx context.Context, id string) error {
+	comment, err := r.ModRepository.FindByID(ctx, id)
+	if err != nil {
+		defer r.purge(ctx)
+	} else {
+		defer r.invalidate(ctx, comment.ModID)
+	}
+	return r.ModRepository.Delete(ctx, id)
+}
+
+func (r *cachedRepository) DeleteComments(ctx context.Context, comments []*models.Comment) error {
+	defer r.invalidateAll(ctx, comments)
+	return r.ModRepository.DeleteComments(ctx, comments)
+}
+
+func (r *cachedRepository) HideComments(ctx context.Context, comments []*models.Comment) error {
+	defer r.invalidateAll(ctx, comments)
+	return r.ModRepository.HideComments(ctx, comments)
+}
+
+func (r *cachedRepository) Pin(ctx context.Context, comment *models.Comment, position int, pinnedBy string) error {
+	defer r.invalidate(ctx, comment.ModID)
+	return r.ModRepository.Pin(ctx, comment, position, pinnedBy)
+}
+
+func (r *cachedRepository) Unpin(ctx context.Context, comment *models.Comment) error {
+	defer r.invalidate(ctx, comment.ModID)
+	return r.ModRepository.Unpin(ctx, comment)
+}
+
+func (r *cachedRepository) SetHidden(ctx context.Context, comment *models.Comment, hidden bool) error {
+	defer r.invalidate(ctx, comment.ModID)
+	return r.ModRepository.SetHidden(ctx, comment, hidden)
+}
+
+func (r *cachedRepository) Review(ctx context.Context, review *models.Review) error {
+	defer r.invalidate(ctx, review.ModID)
+	return r.ModRepository.Review(ctx, review)
+}
+
+// Erasing, restoring, imports and shadow bans change listings of mods that are not known up front
+func (r *cachedRepository) EraseByUserID(ctx context.Context, userID string, anonymise bool, blankText bool) (int64, error) {
+	defer r.purge(ctx)
+	return r.ModRepository.EraseByUserID(ctx, userID, anonymise, blankText)
+}
+
+func (r *cachedRepository) Restore(ctx context.Context, id string) error {
+	defer r.purge(ctx)
+	return r.ModRepository.Restore(ctx, id)
+}
+
+func (r *cachedRepository) ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error) {
+	defer r.purge(ctx)
+	return r.ModRepository.ImportComments(ctx, comments, overwrite)
+}
+
+func (r *cachedRepository) ShadowBan(ctx context.Context, ban *models.ShadowBan) error {
+	defer r.purge(ctx)
+	return r.ModRepository.ShadowBan(ctx, ban)
+}
+
+func (r *cachedRepository) LiftShadowBan(ctx context.Context, userID string) error {
+	defer r.purge(ctx)
+	return r.ModRepository.LiftShadowBan(ctx, userID)
+}
+
+// Version of the listings of a mod, it changes with every write to the mod and every purge
+type cacheVersion struct {
+	epoch uint64
+	mod   uint64
+}
+
+func (r *cachedRepository) version(modID string) cacheVersion {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return cacheVersion{epoch: r.epoch, mod: r.versions[modID]}
+}
+
+type pendingKey struct{}
+
+// Invalidations made within a transaction
+type pendingInvalidations struct {
+	mu    sync.Mutex
+	mods  map[string]bool
+	purge bool
+}
+
+// Remembers an invalidation of the transaction of ctx, if any
+func (r *cachedRepository) remember(ctx context.Context, modID string, purge bool) {
+	pending, ok := ctx.Value(pendingKey{}).(*pendingInvalidations)
+	if !ok {
+		return
+	}
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	if purge {
+		pending.purge = true
+	} else {
+		pending.mods[modID] = true
+	}
+}
+
+// Repeats the invalidations of a transaction that ended
+func (r *cachedRepository) flush(ctx context.Context, pending *pendingInvalidations) {
+	pending.mu.Lock()
+	defer pending.mu.Unlock()
+	if pending.purge {
+		r.purge(ctx)
+		return
+	}
+	for modID := range pending.mods {
+		r.invalidate(ctx, modID)
+	}
+}
+
+func (r *cachedRepository) invalidate(ctx context.Context, modID string) {
+	r.remember(ctx, modID, false)
+	r.mu.Lock()
+	r.versions[modID]++
+	r.mu.Unlock()
+	r.cache.Invalidate(ctx, modID)
+}
+
+// Invalidates the mods of the comments
+func (r *cachedRepository) invalidateAll(ctx context.Context, comments []*models.Comment) {
+	invalidated := map[string]bool{}
+	for _, comment := range comments {
+		if !invalidated[comment.ModID] {
+			invalidated[comment.ModID] = true
+			r.invalidate(ctx, comment.ModID)
+		}
+	}
+}
+
+func (r *cachedRepository) purge(ctx context.Context) {
+	r.remember(ctx, "", true)
+	r.mu.Lock()
+	r.epoch++
+	r.versions = map[string]uint64{}
+	r.mu.Unlock()
+	r.cache.Purge(ctx)
+}
+
+// Key of the listing the options select within a mod
+func cacheKey(options ListOptions) string {
+	return fmt.Sprintf("%d/%d/%d/%s/%t/%t", options.Sort, options.Since.UnixNano(), options.Until.UnixNano(), options.UserID, options.IncludeHidden, options.Viewer.Moderator)
+}
+
+// Copies the comments of a shared listing, so callers can not change the listing of others
+func cloneComments(comments []*models.Comment) []*models.Comment {
+	clone := make([]*models.Comment, 0, len(comments))
+	for _, comment := range comments {
+		copied := *comment
+		clone = append(clone, &copied)
+	}
+	return clone
+}
+
+// Context with the values of its parent but without its cancellation and deadline, like context.WithoutCancel
+type detachedContext struct {
+	parent context.Context
+}
+
+func (c detachedContext) Deadline() (time.Time, bool) {
+	return time.Time{}, false
+}
+
+func (c detachedContext) Done() <-chan struct{} {
+	return nil
+}
+
+func (c detachedContext) Err() error {
+	return nil
+}
+
+func (c detachedContext) Value(key interface{}) interface{} {
+	return c.parent.Value(key)
+}
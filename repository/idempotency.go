@@ -0,0 +1,66 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm/clause"
+)
+
+// Reserves an idempotency key, replacing an expired reservation.
+// Returns false when the key is held by a request that has not expired.
+func (p *postgresRepository) ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (bool, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	result := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "caller_id"}, {Name: "key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"request_hash", "comment_id", "status", "created_at", "expires_at"}),
+		Where:     clause.Where{Exprs: []clause.Expression{clause.Expr{SQL: `idempotency_keys.expires_at <= ?`, Vars: []interface{}{key.CreatedAt}}}},
+	}).Create(key)
+	return result.RowsAffected > 0, result.Error
+}
+
+func (p *postgresRepository) FindIdempotencyKey(ctx context.Context, callerID string, key string) (*models.IdempotencyKey, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var idempotencyKey models.IdempotencyKey
+	err := db.Where(`caller_id = ? AND key = ?`, callerID, key).First(&idempotencyKey).Error
+	return &idempotencyKey, err
+}
+
+// Records the result of the request that reserved the key
+func (p *postgresRepository) CompleteIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Model(&models.IdempotencyKey{}).
+		Where(`caller_id = ? AND key = ?`, key.CallerID, key.Key).
+		Updates(map[string]interface{}{"comment_id": key.CommentID, "status": key.Status}).Error
+}
+
+// Frees a key whose request failed, so a retry can run again
+func (p *postgresRepository) ReleaseIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Where(`caller_id = ? AND key = ? AND comment_id = ''`, key.CallerID, key.Key).Delete(&models.IdempotencyKey{}).Error
+}
+
+// Deletes the keys that expired before a time, in batches
+func (p *postgresRepository) PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error) {
+	var purged int64
+	for {
+		db, cancel := p.session(ctx)
+		result := db.Where(`ctid IN (?)`, db.Model(&models.IdempotencyKey{}).Select(`ctid`).Where(`expires_at <= ?`, before).Limit(batchSize)).
+			Delete(&models.IdempotencyKey{})
+		cancel()
+		if result.Error != nil {
+			return purged, result.Error
+		}
+		purged += result.RowsAffected
+		if result.RowsAffected < batchSize {
+			return purged, nil
+		}
+	}
+}
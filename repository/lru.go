@@ -0,0 +1,91 @@
+package repository
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+)
+
+// In-process cache that evicts the least recently used listing beyond its size. Listings expire
+// after ttl, which bounds how long writes made through other instances of the service go unseen.
+type lruCache struct {
+	size    int
+	ttl     time.Duration
+	mu      sync.Mutex
+	order   *list.List
+	entries map[string]map[string]*list.Element
+	now     func() time.Time
+}
+
+type lruEntry struct {
+	modID    string
+	key      string
+	comments []*models.Comment
+	expires  time.Time
+}
+
+func NewLRUCache(size int, ttl time.Duration) *lruCache {
+	return &lruCache{size: size, ttl: ttl, order: list.New(), entries: map[string]map[string]*list.Element{}, now: time.Now}
+}
+
+func (c *lruCache) Get(ctx context.Context, modID string, key string) ([]*models.Comment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	element, ok := c.entries[modID][key]
+	if !ok {
+		return nil, false
+	}
+	entry := element.Value.(*lruEntry)
+	if c.ttl > 0 && c.now().After(entry.expires) {
+		c.remove(element)
+		return nil, false
+	}
+	c.order.MoveToFront(element)
+	return entry.comments, true
+}
+
+func (c *lruCache) Set(ctx context.Context, modID string, key string, comments []*models.Comment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if element, ok := c.entries[modID][key]; ok {
+		c.remove(element)
+	}
+	if c.entries[modID] == nil {
+		c.entries[modID] = map[string]*list.Element{}
+	}
+	c.entries[modID][key] = c.order.PushFront(&lruEntry{modID: modID, key: key, comments: comments, expires: c.now().Add(c.ttl)})
+	for c.order.Len() > c.size {
+		c.remove(c.order.Back())
+	}
+}
+
+func (c *lruCache) Invalidate(ctx context.Context, modID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	for _, element := range c.entries[modID] {
+		c.order.Remove(element)
+	}
+	delete(c.entries, modID)
+}
+
+func (c *lruCache) Purge(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	c.entries = map[string]map[string]*list.Element{}
+}
+
+func (c *lruCache) remove(element *list.Element) {
+	entry := c.order.Remove(element).(*lruEntry)
+	delete(c.entries[entry.modID], entry.key)
+	if len(c.entries[entry.modID]) == 0 {
+		delete(c.entries, entry.modID)
+	}
+}
@@ -0,0 +1,95 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+)
+
+// Replaces the mentions of a comment with the given users, returning only the newly added mentions
+func (p *postgresRepository) SyncMentions(ctx context.Context, comment *models.Comment, userIDs []string) ([]*models.Mention, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var added []*models.Mention
+	err := db.Transaction(func(tx *gorm.DB) error {
+		var existing []string
+		if err := tx.Model(&models.Mention{}).Where(`comment_id = ?`, comment.ID).Pluck("user_id", &existing).Error; err != nil {
+			return err
+		}
+
+		keep := make(map[string]bool, len(userIDs))
+		for _, userID := range userIDs {
+			keep[userID] = true
+		}
+
+		var removed []string
+		for _, userID := range existing {
+			if keep[userID] {
+				delete(keep, userID)
+			} else {
+				removed = append(removed, userID)
+			}
+		}
+		if len(removed) > 0 {
+			if err := tx.Where(`comment_id = ? AND user_id IN ?`, comment.ID, removed).Delete(&models.Mention{}).Error; err != nil {
+				return err
+			}
+		}
+
+		// Keep the order of the text for the added mentions
+		for _, userID := range userIDs {
+			if keep[userID] {
+				added = append(added, &models.Mention{CommentID: comment.ID, ModID: comment.ModID, AuthorID: comment.UserID, UserID: userID})
+			}
+		}
+		if len(added) == 0 {
+			return nil
+		}
+		return tx.Create(&added).Error
+	})
+	return added, err
+}
+
+// Returns a page of the mentions of a user on visible comments, newest first.
+// Mentions by authors the user has blocked are left out.
+func (p *postgresRepository) SearchMentionsByUserID(ctx context.Context, userID string, pagination models.Pagination) ([]*models.Mention, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Mention{}).
+		Joins(`JOIN comments ON comments.id = mentions.comment_id AND comments.deleted_at IS NULL AND comments.hidden = false`).
+		Where(`mentions.user_id = ?`, userID).
+		Scopes(Viewer{UserID: userID}.filter).
+		Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var l []*models.Mention
+	err := query.Order(`mentions.created_at DESC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	if err != nil || len(l) == 0 {
+		return l, count, err
+	}
+
+	// Attach the mentioning comments
+	ids := make([]string, 0, len(l))
+	for _, mention := range l {
+		ids = append(ids, mention.CommentID)
+	}
+	var comments []*models.Comment
+	if err := db.Where(`id IN ?`, ids).Find(&comments).Error; err != nil {
+		return nil, 0, err
+	}
+	byID := make(map[string]*models.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+	for _, mention := range l {
+		mention.Comment = byID[mention.CommentID]
+	}
+	return l, count, nil
+}
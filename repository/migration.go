@@ -0,0 +1,78 @@
+package repository
+
+import (
+	"fmt"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+)
+
+// Statements that give the comments table a unique UUID primary key. Comments used to embed
+// gorm.Model next to a string ID, leaving the id column without a key and letting updates
+// of an unknown comment insert a second row with the same id.
+var commentIDMigration = []string{
+	`UPDATE comments SET id = uuid_generate_v4() WHERE id IS NULL`,
+	// Keep the latest write of a duplicated id, dated at its first write
+	`UPDATE comments SET created_at = first.created_at
+		FROM (SELECT id, min(created_at) AS created_at FROM comments GROUP BY id HAVING count(*) > 1) AS first
+		WHERE comments.id = first.id`,
+	`DELETE FROM comments AS older USING comments AS newer
+		WHERE older.id = newer.id AND (older.updated_at, older.ctid) < (newer.updated_at, newer.ctid)`,
+	`ALTER TABLE comments ALTER COLUMN id SET DEFAULT uuid_generate_v4()`,
+	`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conrelid = 'comments'::regclass AND contype = 'p') THEN
+			ALTER TABLE comments ADD PRIMARY KEY (id);
+		END IF;
+	END $$`,
+}
+
+// Migrates an existing comments table to the UUID primary key, before the schema is auto migrated.
+// A table that has its primary key is migrated already and left untouched.
+func migrateCommentIDs(db *gorm.DB) error {
+	if !db.Migrator().HasTable(&models.Comment{}) {
+		return nil
+	}
+
+	var keys int64
+	err := db.Raw(`SELECT count(*) FROM pg_constraint WHERE conrelid = 'comments'::regclass AND contype = 'p'`).Scan(&keys).Error
+	if err != nil || keys > 0 {
+		return err
+	}
+
+	var dataType string
+	err = db.Raw(`SELECT data_type FROM information_schema.columns WHERE table_name = 'comments' AND column_name = 'id'`).Scan(&dataType).Error
+	if err != nil {
+		return err
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		switch dataType {
+		case "uuid":
+		case "text", "character varying":
+			err = tx.Exec(`ALTER TABLE comments ALTER COLUMN id DROP DEFAULT, ALTER COLUMN id TYPE uuid USING id::uuid`).Error
+		default:
+			// A numeric id of gorm.Model can not be referenced by other tables, new ids lose nothing
+			err = tx.Exec(`ALTER TABLE comments ALTER COLUMN id DROP DEFAULT, ALTER COLUMN id TYPE uuid USING uuid_generate_v4()`).Error
+		}
+		if err != nil {
+			return err
+		}
+		for _, statement := range commentIDMigration {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Statement that references comments from the comment_id of a table, after removing rows of
+// comments that no longer exist. Hard deleted comments take their rows with them.
+func commentForeignKey(table string) string {
+	return fmt.Sprintf(`DO $$ BEGIN
+		IF NOT EXISTS (SELECT 1 FROM pg_constraint WHERE conname = 'fk_%[1]s_comment') THEN
+			DELETE FROM %[1]s WHERE comment_id NOT IN (SELECT id FROM comments);
+			ALTER TABLE %[1]s ADD CONSTRAINT fk_%[1]s_comment FOREIGN KEY (comment_id) REFERENCES comments (id) ON DELETE CASCADE;
+		END IF;
+	END $$`, table)
+}
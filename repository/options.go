@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type SortMode int
+
+// Supported orderings of a comment listing
+const (
+	SortNewest SortMode = iota
+	SortOldest
+)
+
+// Fixed order clauses, request values never end up in the query text
+var sortClauses = map[SortMode]string{
+	SortNewest: `created_at DESC`,
+	SortOldest: `created_at ASC`,
+}
+
+// The caller a listing is read for, empty when anonymous
+type Viewer struct {
+	UserID    string
+	Moderator bool
+}
+
+// Restrict a comment query to the comments the viewer may and wants to see.
+// Pending comments are only shown to their author, rejected comments to nobody.
+// Comments of shadow-banned users are only shown to their author and moderators.
+func (v Viewer) filter(db *gorm.DB) *gorm.DB {
+	if v.UserID != "" {
+		db = db.Where(`comments.status = 'published' OR (comments.status = 'pending' AND comments.user_id = ?)`, v.UserID)
+	} else {
+		db = db.Where(`comments.status = 'published'`)
+	}
+	db = v.shadowBans(db)
+	if v.UserID != "" {
+		db = db.Where(`comments.user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = ?)`, v.UserID)
+	}
+	return db
+}
+
+// Leave out the comments of shadow-banned users unless the viewer is a moderator or their author
+func (v Viewer) shadowBans(db *gorm.DB) *gorm.DB {
+	if v.Moderator {
+		return db
+	}
+	if v.UserID != "" {
+		return db.Where(`comments.user_id NOT IN (SELECT user_id FROM shadow_bans) OR comments.user_id = ?`, v.UserID)
+	}
+	return db.Where(`comments.user_id NOT IN (SELECT user_id FROM shadow_bans)`)
+}
+
+type ListOptions struct {
+	Sort          SortMode
+	Since         time.Time
+	Until         time.Time
+	UserID        string
+	IncludeHidden bool
+	Viewer        Viewer
+}
+
+// Apply the filters of the options to a comment query
+func (o ListOptions) filter(db *gorm.DB) *gorm.DB {
+	if !o.IncludeHidden {
+		db = db.Where(`hidden = false`)
+	}
+	if !o.Since.IsZero() {
+		db = db.Where(`created_at >= ?`, o.Since)
+	}
+	if !o.Until.IsZero() {
+		db = db.Where(`created_at < ?`, o.Until)
+	}
+	if o.UserID != "" {
+		db = db.Where(`user_id = ?`, o.UserID)
+	}
+	return o.Viewer.filter(db)
+}
+
+// Apply the filters and ordering of the options to a comment query
+func (o ListOptions) scope(db *gorm.DB) *gorm.DB {
+	order, ok := sortClauses[o.Sort]
+	if !ok {
+		order = sortClauses[SortNewest]
+	}
+	return o.filter(db).Order(order)
+}
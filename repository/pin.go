@@ -0,0 +1,112 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrPinLimitReached = errors.New("maximum number of pinned comments reached")
+
+// Pins a comment at a 1-based position of its mod, or moves it when already pinned.
+// Positions out of range append the comment after the existing pins.
+func (p *postgresRepository) Pin(ctx context.Context, comment *models.Comment, position int, pinnedBy string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Serialise pin changes per mod so the limit holds under concurrency
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext(?))`, comment.ModID).Error; err != nil {
+			return err
+		}
+
+		var pins []*models.Pin
+		if err := tx.Where(`mod_id = ?`, comment.ModID).Order(`position`).Find(&pins).Error; err != nil {
+			return err
+		}
+
+		ordered := make([]*models.Pin, 0, len(pins)+1)
+		for _, pin := range pins {
+			if pin.CommentID != comment.ID {
+				ordered = append(ordered, pin)
+			}
+		}
+		if len(ordered) >= models.MaxPinsPerMod {
+			return ErrPinLimitReached
+		}
+		if position < 1 || position > len(ordered)+1 {
+			position = len(ordered) + 1
+		}
+
+		pin := &models.Pin{CommentID: comment.ID, ModID: comment.ModID, PinnedBy: pinnedBy}
+		ordered = append(ordered[:position-1], append([]*models.Pin{pin}, ordered[position-1:]...)...)
+		for i, pin := range ordered {
+			pin.Position = i + 1
+		}
+
+		return tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "comment_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"position"}),
+		}).Create(&ordered).Error
+	})
+}
+
+// Unpins a comment and closes the gap in the positions of its mod
+func (p *postgresRepository) Unpin(ctx context.Context, comment *models.Comment) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Exec(`SELECT pg_advisory_xact_lock(hashtext(?))`, comment.ModID).Error; err != nil {
+			return err
+		}
+		return unpin(tx, comment.ID)
+	})
+}
+
+func unpin(tx *gorm.DB, commentID string) error {
+	var pins []*models.Pin
+	if err := tx.Clauses(clause.Returning{}).Where(`comment_id = ?`, commentID).Delete(&pins).Error; err != nil {
+		return err
+	}
+	for _, pin := range pins {
+		err := tx.Model(&models.Pin{}).
+			Where(`mod_id = ? AND position > ?`, pin.ModID, pin.Position).
+			Update("position", gorm.Expr(`position - 1`)).Error
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Moves the pinned comments of a mod to the front of a listing in pin order and flags them
+func applyPins(db *gorm.DB, modID string, comments []*models.Comment) ([]*models.Comment, error) {
+	var pins []*models.Pin
+	if err := db.Where(`mod_id = ?`, modID).Order(`position`).Find(&pins).Error; err != nil {
+		return nil, err
+	}
+	if len(pins) == 0 {
+		return comments, nil
+	}
+
+	byID := make(map[string]*models.Comment, len(comments))
+	for _, comment := range comments {
+		byID[comment.ID] = comment
+	}
+
+	result := make([]*models.Comment, 0, len(comments))
+	for _, pin := range pins {
+		if comment, ok := byID[pin.CommentID]; ok {
+			comment.Pinned = true
+			result = append(result, comment)
+		}
+	}
+	for _, comment := range comments {
+		if !comment.Pinned {
+			result = append(result, comment)
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,82 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+)
+
+// Returns the rows that carry the id of a user outside its comments
+func (p *postgresRepository) SearchUserRecords(ctx context.Context, userID string) (*models.UserRecords, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	records := &models.UserRecords{}
+	if err := db.Where(`author_id = ? OR user_id = ?`, userID, userID).Order(`created_at`).Find(&records.Mentions).Error; err != nil {
+		return nil, err
+	}
+	if err := db.Where(`blocker_id = ?`, userID).Order(`created_at`).Find(&records.Blocks).Error; err != nil {
+		return nil, err
+	}
+	var ban models.ShadowBan
+	err := db.Where(`user_id = ?`, userID).Take(&ban).Error
+	if err == nil {
+		records.ShadowBan = &ban
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := db.Where(`user_id = ?`, userID).Order(`created_at`).Find(&records.Reviews).Error; err != nil {
+		return nil, err
+	}
+	err = db.Where(`caller_id = ?`, userID).Order(`created_at`).Find(&records.IdempotencyKeys).Error
+	return records, err
+}
+
+// Deletes the rows of a user outside its comments, anonymise keeps the mentions it wrote and the reviews of its
+// comments under the tombstone. Actions the user took as moderator keep their effect under the tombstone.
+func (p *postgresRepository) eraseUserRecords(ctx context.Context, userID string, anonymise bool) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		if anonymise {
+			if err := tx.Model(&models.Mention{}).Where(`author_id = ?`, userID).UpdateColumn("author_id", models.TombstoneUserID).Error; err != nil {
+				return err
+			}
+			if err := tx.Model(&models.Review{}).Where(`user_id = ?`, userID).UpdateColumn("user_id", models.TombstoneUserID).Error; err != nil {
+				return err
+			}
+		} else {
+			if err := tx.Where(`author_id = ?`, userID).Delete(&models.Mention{}).Error; err != nil {
+				return err
+			}
+			if err := tx.Where(`user_id = ?`, userID).Delete(&models.Review{}).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where(`user_id = ?`, userID).Delete(&models.Mention{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(`blocker_id = ? OR blocked_id = ?`, userID, userID).Delete(&models.Block{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(`user_id = ?`, userID).Delete(&models.ShadowBan{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Where(`caller_id = ?`, userID).Delete(&models.IdempotencyKey{}).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Review{}).Where(`reviewed_by = ?`, userID).UpdateColumn("reviewed_by", models.TombstoneUserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.ShadowBan{}).Where(`banned_by = ?`, userID).UpdateColumn("banned_by", models.TombstoneUserID).Error; err != nil {
+			return err
+		}
+		if err := tx.Model(&models.Pin{}).Where(`pinned_by = ?`, userID).UpdateColumn("pinned_by", models.TombstoneUserID).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ModerationSetting{}).Where(`updated_by = ?`, userID).UpdateColumn("updated_by", models.TombstoneUserID).Error
+	})
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Number of pinned users above which expired pins are swept
+const maxPins = 10000
+
+// Send reads that tolerate replication lag to the given replicas, writes always go to the primary
+func WithReplicas(replicas ...*gorm.DB) Option {
+	return func(p *postgresRepository) {
+		p.replicas = newReplicaSet(replicas)
+	}
+}
+
+// Read from the primary for window after a user wrote, so users see their own writes despite replication lag
+func WithReadYourWrites(window time.Duration) Option {
+	return func(p *postgresRepository) {
+		p.pins = newPins(window)
+	}
+}
+
+type userKey struct{}
+
+// Returns a context for queries made on behalf of a user, writes pin the user to the primary
+func WithUser(ctx context.Context, userID string) context.Context {
+	return context.WithValue(ctx, userKey{}, userID)
+}
+
+func userFromContext(ctx context.Context) string {
+	userID, _ := ctx.Value(userKey{}).(string)
+	return userID
+}
+
+// Replicas taken in turn, skipping those that failed their last health check
+type replicaSet struct {
+	dbs     []*gorm.DB
+	healthy []atomic.Bool
+	turn    atomic.Uint64
+}
+
+// Replicas start unhealthy until their first health check
+func newReplicaSet(dbs []*gorm.DB) *replicaSet {
+	return &replicaSet{dbs: dbs, healthy: make([]atomic.Bool, len(dbs))}
+}
+
+// Returns the next healthy replica, nil when none is healthy
+func (r *replicaSet) next() *gorm.DB {
+	for range r.dbs {
+		i := int(r.turn.Add(1) % uint64(len(r.dbs)))
+		if r.healthy[i].Load() {
+			return r.dbs[i]
+		}
+	}
+	return nil
+}
+
+// Pings every replica and returns the number of healthy replicas
+func (r *replicaSet) check(ctx context.Context, timeout time.Duration) int {
+	var healthy int
+	for i, db := range r.dbs {
+		r.healthy[i].Store(ping(ctx, db, timeout) == nil)
+		if r.healthy[i].Load() {
+			healthy++
+		}
+	}
+	return healthy
+}
+
+func ping(ctx context.Context, db *gorm.DB, timeout time.Duration) error {
+	conn, err := db.DB()
+	if err != nil {
+		return err
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	return conn.PingContext(ctx)
+}
+
+// Checks the health of the replicas every interval until ctx ends, reporting every change
+// of the number of healthy replicas. The first check runs before it returns.
+func (p *postgresRepository) MonitorReplicas(ctx context.Context, interval time.Duration, report func(healthy int, total int)) {
+	if len(p.replicas.dbs) == 0 {
+		return
+	}
+
+	healthy := p.replicas.check(ctx, interval)
+	report(healthy, len(p.replicas.dbs))
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				if current := p.replicas.check(ctx, interval); current != healthy {
+					healthy = current
+					report(healthy, len(p.replicas.dbs))
+				}
+			}
+		}
+	}()
+}
+
+// Users that read from the primary until their pin expires
+type pins struct {
+	window time.Duration
+	mu     sync.Mutex
+	until  map[string]time.Time
+}
+
+func newPins(window time.Duration) *pins {
+	return &pins{window: window, until: map[string]time.Time{}}
+}
+
+func (p *pins) pin(userID string) {
+	if p.window <= 0 || userID == "" {
+		return
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	if len(p.until) >= maxPins {
+		for pinned, until := range p.until {
+			if now.After(until) {
+				delete(p.until, pinned)
+			}
+		}
+	}
+	p.until[userID] = now.Add(p.window)
+}
+
+func (p *pins) pinned(userID string) bool {
+	if p.window <= 0 || userID == "" {
+		return false
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	until, ok := p.until[userID]
+	return ok && time.Now().Before(until)
+}
+
+// Pins the user of a successful write to the primary
+func (p *postgresRepository) pinWriter(db *gorm.DB) {
+	if db.Error != nil || db.RowsAffected == 0 || db.Statement.Context == nil {
+		return
+	}
+	p.pins.pin(userFromContext(db.Statement.Context))
+}
@@ -1,40 +1,440 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
 	"github.com/mxbikes/mxbikesclient.service.comment/models"
 	"gorm.io/gorm"
 )
 
+var ErrVersionConflict = errors.New("comment was changed by another request")
+
+// Number of rows read or written per statement by bulk operations
+const batchSize = 500
+
+// Indexes that can not be expressed with struct tags
+var indexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_comments_mod_id_created_at ON comments (mod_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_visible_mod_id_created_at ON comments (mod_id, created_at) WHERE hidden = false AND deleted_at IS NULL`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_mod_id_user_id_created_at ON comments (mod_id, user_id, created_at)`,
+	`CREATE INDEX IF NOT EXISTS idx_comments_pending_created_at ON comments (created_at) WHERE status = 'pending' AND deleted_at IS NULL`,
+}
+
 type ModRepository interface {
-	SearchByModID(modID string) ([]*models.Comment, error)
-	Save(comment *models.Comment) error
-	Delete(id string) error
-	Migrate() error
+	SearchByModID(ctx context.Context, modID string, options ListOptions) ([]*models.Comment, error)
+	SearchByModIDs(ctx context.Context, modIDs []string, limit int, viewer Viewer) (map[string][]*models.Comment, error)
+	SearchAllByUserID(ctx context.Context, userID string) ([]*models.Comment, error)
+	SearchByUserID(ctx context.Context, userID string, modID string, pagination models.Pagination, viewer Viewer) ([]*models.Comment, int64, error)
+	FindByID(ctx context.Context, id string) (*models.Comment, error)
+	FindVisibleByID(ctx context.Context, id string, viewer Viewer) (*models.Comment, error)
+	Create(ctx context.Context, comment *models.Comment) error
+	Update(ctx context.Context, comment *models.Comment, changes map[string]interface{}) error
+	Delete(ctx context.Context, id string) error
+	FindByIDs(ctx context.Context, ids []string) ([]*models.Comment, error)
+	SearchByFilter(ctx context.Context, filter models.CommentFilter, limit int) ([]*models.Comment, error)
+	CountByFilter(ctx context.Context, filter models.CommentFilter) (int64, error)
+	ValidTextPattern(ctx context.Context, pattern string) (bool, error)
+	DeleteComments(ctx context.Context, comments []*models.Comment) error
+	HideComments(ctx context.Context, comments []*models.Comment) error
+	Restore(ctx context.Context, id string) error
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+	CommentStats(ctx context.Context) (*models.CommentStats, error)
+	StreamComments(ctx context.Context, filter models.CommentFilter, fn func(comments []*models.Comment) error) error
+	ImportComments(ctx context.Context, comments []*models.Comment, overwrite bool) ([]string, error)
+	Pin(ctx context.Context, comment *models.Comment, position int, pinnedBy string) error
+	Unpin(ctx context.Context, comment *models.Comment) error
+	SetHidden(ctx context.Context, comment *models.Comment, hidden bool) error
+	EraseByUserID(ctx context.Context, userID string, anonymise bool, blankText bool) (int64, error)
+	SearchUserRecords(ctx context.Context, userID string) (*models.UserRecords, error)
+	SyncMentions(ctx context.Context, comment *models.Comment, userIDs []string) ([]*models.Mention, error)
+	SearchMentionsByUserID(ctx context.Context, userID string, pagination models.Pagination) ([]*models.Mention, int64, error)
+	Block(ctx context.Context, blockerID string, blockedID string) error
+	Unblock(ctx context.Context, blockerID string, blockedID string) error
+	SearchBlocked(ctx context.Context, blockerID string, pagination models.Pagination) ([]*models.Block, int64, error)
+	SearchBlockers(ctx context.Context, blockedID string, userIDs []string) ([]string, error)
+	ShadowBan(ctx context.Context, ban *models.ShadowBan) error
+	LiftShadowBan(ctx context.Context, userID string) error
+	SearchShadowBans(ctx context.Context, pagination models.Pagination) ([]*models.ShadowBan, int64, error)
+	IsShadowBanned(ctx context.Context, userID string) (bool, error)
+	Review(ctx context.Context, review *models.Review) error
+	CountApproved(ctx context.Context, userID string) (int64, error)
+	SearchPending(ctx context.Context, modID string, pagination models.Pagination, viewer Viewer) ([]*models.Comment, int64, error)
+	IsPreModerated(ctx context.Context, modID string) (bool, error)
+	SetPreModerated(ctx context.Context, setting *models.ModerationSetting) error
+	SearchByCreatedAt(ctx context.Context, modID string, userID string, since time.Time, until time.Time, limit int, viewer *Viewer) ([]*models.Comment, error)
+	ReserveIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) (bool, error)
+	FindIdempotencyKey(ctx context.Context, callerID string, key string) (*models.IdempotencyKey, error)
+	CompleteIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	ReleaseIdempotencyKey(ctx context.Context, key *models.IdempotencyKey) error
+	PurgeIdempotencyKeys(ctx context.Context, before time.Time) (int64, error)
+	Transaction(ctx context.Context, fn func(ctx context.Context) error) error
+	Audit(ctx context.Context, entries ...*models.AuditLog) error
+	SearchAuditLog(ctx context.Context, filter models.AuditFilter, pagination models.Pagination) ([]*models.AuditLog, int64, error)
+	VerifyAuditLog(ctx context.Context) (*models.AuditVerification, error)
+	Migrate(ctx context.Context) error
 }
 
 type postgresRepository struct {
-	db *gorm.DB
+	db           *gorm.DB
+	replicas     *replicaSet
+	pins         *pins
+	queryTimeout time.Duration
+}
+
+// Option configures optional behaviour of the repository
+type Option func(*postgresRepository)
+
+// Cancel every query that runs longer than timeout, queries only end with their context when zero
+func WithQueryTimeout(timeout time.Duration) Option {
+	return func(p *postgresRepository) {
+		p.queryTimeout = timeout
+	}
+}
+
+func NewRepository(c *gorm.DB, opts ...Option) *postgresRepository {
+	repository := &postgresRepository{db: c, replicas: newReplicaSet(nil), pins: newPins(0)}
+	for _, opt := range opts {
+		opt(repository)
+	}
+
+	registerCallbacks(c)
+	for _, replica := range repository.replicas.dbs {
+		registerCallbacks(replica)
+	}
+	callbacks := c.Callback()
+	callbacks.Create().After("*").Register("repository:pin_writer", repository.pinWriter)
+	callbacks.Update().After("*").Register("repository:pin_writer", repository.pinWriter)
+	callbacks.Delete().After("*").Register("repository:pin_writer", repository.pinWriter)
+	callbacks.Raw().After("*").Register("repository:pin_writer", repository.pinWriter)
+	return repository
+}
+
+func registerCallbacks(db *gorm.DB) {
+	callbacks := db.Callback()
+	callbacks.Create().After("*").Register("repository:context_error", contextError)
+	callbacks.Query().After("*").Register("repository:context_error", contextError)
+	callbacks.Update().After("*").Register("repository:context_error", contextError)
+	callbacks.Delete().After("*").Register("repository:context_error", contextError)
+	callbacks.Row().After("*").Register("repository:context_error", contextError)
+	callbacks.Raw().After("*").Register("repository:context_error", contextError)
+}
+
+// Wraps the error of a query ended by its context with the context error,
+// drivers report cancelled queries in their own words
+func contextError(db *gorm.DB) {
+	if db.Error == nil || db.Statement.Context == nil {
+		return
+	}
+	if err := db.Statement.Context.Err(); err != nil && !errors.Is(db.Error, err) {
+		db.Error = fmt.Errorf("%w: %v", err, db.Error)
+	}
+}
+
+type txKey struct{}
+
+// Runs fn in one transaction on the primary. Every call made with the context fn receives takes part
+// in it, so a change and its audit entry are committed or rolled back together.
+func (p *postgresRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx)
+	}
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		// Transactions of the calls join this one instead of nesting in a savepoint
+		return fn(context.WithValue(ctx, txKey{}, tx.Session(&gorm.Session{DisableNestedTransaction: true})))
+	})
+}
+
+// Returns a session on the primary bound to ctx and limited to the query timeout, cancel releases the timer.
+// Within a transaction the session belongs to it.
+func (p *postgresRepository) session(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return p.bind(ctx, tx)
+	}
+	return p.bind(ctx, p.db)
+}
+
+// Returns a session for reads that tolerate replication lag. Reads go to a healthy replica,
+// or to the primary when there is none, within a transaction or when the user of ctx wrote
+// within the read-your-writes window.
+func (p *postgresRepository) replica(ctx context.Context) (*gorm.DB, context.CancelFunc) {
+	if ctx.Value(txKey{}) != nil || p.pins.pinned(userFromContext(ctx)) {
+		return p.session(ctx)
+	}
+	db := p.replicas.next()
+	if db == nil {
+		return p.session(ctx)
+	}
+	return p.bind(ctx, db)
+}
+
+func (p *postgresRepository) bind(ctx context.Context, db *gorm.DB) (*gorm.DB, context.CancelFunc) {
+	if p.queryTimeout <= 0 {
+		return db.WithContext(ctx), func() {}
+	}
+	ctx, cancel := context.WithTimeout(ctx, p.queryTimeout)
+	return db.WithContext(ctx), cancel
+}
+
+func (p *postgresRepository) SearchByModID(ctx context.Context, modID string, options ListOptions) ([]*models.Comment, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var l []*models.Comment
+	err := db.Where(`mod_id = ?`, modID).Scopes(options.scope).Find(&l).Error
+	if err != nil {
+		return nil, err
+	}
+	return applyPins(db, modID, l)
+}
+
+// Returns the latest comments of each mod, at most limit per mod, using a single windowed query
+func (p *postgresRepository) SearchByModIDs(ctx context.Context, modIDs []string, limit int, viewer Viewer) (map[string][]*models.Comment, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var l []*models.Comment
+	ranked := db.Model(&models.Comment{}).
+		Select(`*, ROW_NUMBER() OVER (PARTITION BY mod_id ORDER BY created_at DESC) AS row_rank`).
+		Where(`mod_id IN ?`, modIDs).
+		Where(`hidden = false`).
+		Scopes(viewer.filter)
+	err := db.Raw(`SELECT * FROM (?) AS ranked WHERE row_rank <= ? ORDER BY mod_id, row_rank`, ranked, limit).Scan(&l).Error
+	if err != nil {
+		return nil, err
+	}
+
+	result := make(map[string][]*models.Comment, len(modIDs))
+	for _, comment := range l {
+		result[comment.ModID] = append(result[comment.ModID], comment)
+	}
+	return result, nil
 }
 
-func NewRepository(c *gorm.DB) *postgresRepository {
-	return &postgresRepository{db: c}
+// Returns a page of the comments of a user, newest first, optionally limited to one mod
+func (p *postgresRepository) SearchByUserID(ctx context.Context, userID string, modID string, pagination models.Pagination, viewer Viewer) ([]*models.Comment, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Comment{}).Where(`user_id = ?`, userID).Where(`hidden = false`)
+	if modID != "" {
+		query = query.Where(`mod_id = ?`, modID)
+	}
+	query = query.Scopes(viewer.filter).Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var l []*models.Comment
+	err := query.Order(`created_at DESC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	return l, count, err
 }
 
-func (p *postgresRepository) SearchByModID(modID string) ([]*models.Comment, error) {
+// Returns the comments created in a window, newest first, optionally limited to one mod or user.
+// Without a viewer comments of every status are returned, hidden ones included.
+func (p *postgresRepository) SearchByCreatedAt(ctx context.Context, modID string, userID string, since time.Time, until time.Time, limit int, viewer *Viewer) ([]*models.Comment, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	query := db.Where(`created_at >= ?`, since)
+	if !until.IsZero() {
+		query = query.Where(`created_at < ?`, until)
+	}
+	if modID != "" {
+		query = query.Where(`mod_id = ?`, modID)
+	}
+	if userID != "" {
+		query = query.Where(`user_id = ?`, userID)
+	}
+	if viewer != nil {
+		query = query.Where(`hidden = false`).Scopes(viewer.filter)
+	}
+
 	var l []*models.Comment
-	err := p.db.Where(`mod_id = ?`, modID).Find(&l).Error
+	err := query.Order(`created_at DESC`).Limit(limit).Find(&l).Error
+	return l, err
+}
+
+func (p *postgresRepository) FindByID(ctx context.Context, id string) (*models.Comment, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var comment models.Comment
+	err := db.Where(`id = ?`, id).First(&comment).Error
+	return &comment, err
+}
+
+// Returns a comment unless the viewer may not see its author
+func (p *postgresRepository) FindVisibleByID(ctx context.Context, id string, viewer Viewer) (*models.Comment, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	var comment models.Comment
+	err := db.Where(`id = ?`, id).Scopes(viewer.filter).First(&comment).Error
+	return &comment, err
+}
+
+// Returns every comment of a user, soft deleted ones included
+func (p *postgresRepository) SearchAllByUserID(ctx context.Context, userID string) ([]*models.Comment, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var l, batch []*models.Comment
+	err := db.Unscoped().Where(`user_id = ?`, userID).FindInBatches(&batch, batchSize, func(tx *gorm.DB, _ int) error {
+		l = append(l, batch...)
+		return nil
+	}).Error
 	return l, err
 }
 
-func (p *postgresRepository) Save(comment *models.Comment) error {
-	return p.db.Save(comment).Error
+// Inserts a new comment, an existing id is never overwritten
+func (p *postgresRepository) Create(ctx context.Context, comment *models.Comment) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	omit := []string{"hidden", "version"}
+	if comment.Status == "" {
+		omit = append(omit, "status")
+	}
+	if comment.SpamScore == nil {
+		omit = append(omit, "spam_score")
+	}
+	return db.Omit(omit...).Create(comment).Error
+}
+
+// Writes the changed columns of a comment, unless another request changed it since it was read at comment.Version.
+// Hidden is only changed through SetHidden, so an edit never reveals a hidden comment.
+func (p *postgresRepository) Update(ctx context.Context, comment *models.Comment, changes map[string]interface{}) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	values := map[string]interface{}{"version": gorm.Expr("version + 1")}
+	for column, value := range changes {
+		if column != "hidden" {
+			values[column] = value
+		}
+	}
+	result := db.Model(&models.Comment{}).Where(`id = ? AND version = ?`, comment.ID, comment.Version).Updates(values)
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return updateConflict(db, comment.ID)
+	}
+	comment.Version++
+	return nil
+}
+
+// Explains why a conditional update changed nothing, the comment is gone or has a newer version
+func updateConflict(db *gorm.DB, id string) error {
+	var count int64
+	if err := db.Model(&models.Comment{}).Where(`id = ?`, id).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return ErrVersionConflict
 }
 
-func (p *postgresRepository) Delete(id string) error {
-	return p.db.Delete(&models.Comment{ID: id}).Error
+// Hides or reveals a comment, hidden comments lose their pin
+func (p *postgresRepository) SetHidden(ctx context.Context, comment *models.Comment, hidden bool) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.Comment{}).Where(`id = ?`, comment.ID).Update("hidden", hidden).Error; err != nil {
+			return err
+		}
+		if !hidden {
+			return nil
+		}
+		return unpin(tx, comment.ID)
+	})
+}
+
+func (p *postgresRepository) Delete(ctx context.Context, id string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Delete(&models.Comment{ID: id}).Error; err != nil {
+			return err
+		}
+		return unpin(tx, id)
+	})
+}
+
+// Hard deletes or anonymises every comment of a user, one batch per statement, then the other rows of the user
+func (p *postgresRepository) EraseByUserID(ctx context.Context, userID string, anonymise bool, blankText bool) (int64, error) {
+	var affected int64
+	for {
+		erased, err := p.eraseBatch(ctx, userID, anonymise, blankText)
+		affected += erased
+		if err != nil {
+			return affected, err
+		}
+		if erased == 0 {
+			return affected, p.eraseUserRecords(ctx, userID, anonymise)
+		}
+	}
+}
+
+// Erases one batch of the comments of a user, every batch has its own query timeout
+func (p *postgresRepository) eraseBatch(ctx context.Context, userID string, anonymise bool, blankText bool) (int64, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var ids []string
+	err := db.Unscoped().Model(&models.Comment{}).Where(`user_id = ?`, userID).Limit(batchSize).Pluck("id", &ids).Error
+	if err != nil || len(ids) == 0 {
+		return 0, err
+	}
+
+	var result *gorm.DB
+	if anonymise {
+		updates := map[string]interface{}{"user_id": models.TombstoneUserID}
+		if blankText {
+			updates["text"] = ""
+		}
+		result = db.Unscoped().Model(&models.Comment{}).Where(`id IN ?`, ids).Updates(updates)
+	} else {
+		result = db.Unscoped().Where(`id IN ?`, ids).Delete(&models.Comment{})
+	}
+	return result.RowsAffected, result.Error
 }
 
-func (p *postgresRepository) Migrate() error {
-	p.db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`)
-	return p.db.AutoMigrate(&models.Comment{})
+func (p *postgresRepository) Migrate(ctx context.Context) error {
+	db := p.db.WithContext(ctx)
+	db.Exec(`CREATE EXTENSION IF NOT EXISTS "uuid-ossp";`)
+	if err := migrateCommentIDs(db); err != nil {
+		return err
+	}
+	if err := db.AutoMigrate(&models.Comment{}, &models.AuditLog{}, &models.Mention{}, &models.Pin{}, &models.Block{}, &models.ShadowBan{}, &models.Review{}, &models.ModerationSetting{}, &models.IdempotencyKey{}); err != nil {
+		return err
+	}
+	for _, index := range indexes {
+		if err := db.Exec(index).Error; err != nil {
+			return err
+		}
+	}
+	for _, table := range []string{"mentions", "pins", "reviews"} {
+		if err := db.Exec(commentForeignKey(table)).Error; err != nil {
+			return err
+		}
+	}
+	if err := migrateAuditChain(db); err != nil {
+		return err
+	}
+	for _, statement := range auditAppendOnly {
+		if err := db.Exec(statement).Error; err != nil {
+			return err
+		}
+	}
+	return nil
 }
@@ -1,10 +1,14 @@
 package repository
 
 import (
+	"context"
 	"database/sql"
 	"database/sql/driver"
+	"errors"
 	"log"
 	"regexp"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -34,22 +38,46 @@ func NewMockRepository(db gorm.ConnPool) *postgresRepository {
 	return NewRepository(gdb)
 }
 
+// will test a query that runs longer than the query timeout
+func TestRepositoryQueryTimeout(t *testing.T) {
+	// Arrange
+	id := uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(id).
+		WillDelayFor(time.Second).
+		WillReturnRows(sqlmock.NewRows([]string{"ID"}).AddRow(id))
+
+	repo := NewMockRepository(db)
+	repo.queryTimeout = 10 * time.Millisecond
+
+	// Act
+	_, err := repo.FindByID(context.Background(), id)
+
+	// Assert
+	assert.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
 // will test get by mod id
 func TestRepositoryGetByModID(t *testing.T) {
 	// Arrange
 	var modID = uuid.New()
 
 	db, mock := NewMock()
-	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND "comments"."deleted_at" IS NULL`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC`)).
 		WithArgs(modID).
 		WillReturnRows(sqlmock.
 			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
 			AddRow(uuid.New().String(), modID.String(), "63b2dff9e834e550f0e50e66", "Good Job!"))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(modID.String()).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}))
 
 	repo := NewMockRepository(db)
 
 	// Act
-	l, err := repo.SearchByModID(modID.String())
+	l, err := repo.SearchByModID(context.Background(), modID.String(), ListOptions{})
 
 	// Assert
 	assert.NoError(t, err)
@@ -57,6 +85,136 @@ func TestRepositoryGetByModID(t *testing.T) {
 	assert.Equal(t, l[0].ModID, modID.String())
 }
 
+// will test get by mod id with sorting and filters
+func TestRepositoryGetByModIDWithOptions(t *testing.T) {
+	// Arrange
+	var modID, pinnedID = uuid.NewString(), uuid.NewString()
+	var since = time.Date(2023, 1, 1, 0, 0, 0, 0, time.UTC)
+	var userID = "63b2dff9e834e550f0e50e66"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND created_at >= $2 AND user_id = $3 AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at ASC`)).
+		WithArgs(modID, since, userID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "Hidden"}).
+			AddRow(uuid.NewString(), modID, userID, "Good Job!", true).
+			AddRow(pinnedID, modID, userID, "Known issues", false))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(modID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}).AddRow(pinnedID, modID, 1))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, err := repo.SearchByModID(context.Background(), modID, ListOptions{Sort: SortOldest, Since: since, UserID: userID, IncludeHidden: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, l[0].ID, pinnedID)
+	assert.True(t, l[0].Pinned)
+	assert.True(t, l[1].Hidden)
+	assert.False(t, l[1].Pinned)
+}
+
+// will test get by mod id with only the pending comments of the viewer and without blocked and shadow-banned authors
+func TestRepositoryGetByModIDHiddenAuthors(t *testing.T) {
+	// Arrange
+	var modID = uuid.NewString()
+	var viewerID = "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND hidden = false AND (comments.status = 'published' OR (comments.status = 'pending' AND comments.user_id = $2)) AND (comments.user_id NOT IN (SELECT user_id FROM shadow_bans) OR comments.user_id = $3) AND comments.user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $4) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC`)).
+		WithArgs(modID, viewerID, viewerID, viewerID).
+		WillReturnRows(sqlmock.NewRows([]string{"ID", "ModID", "UserID", "Text"}))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(modID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position"}))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, err := repo.SearchByModID(context.Background(), modID, ListOptions{Viewer: Viewer{UserID: viewerID}})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(l), 0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test get latest comments of many mods
+func TestRepositoryGetByModIDs(t *testing.T) {
+	// Arrange
+	var modA, modB = uuid.NewString(), uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM (SELECT *, ROW_NUMBER() OVER (PARTITION BY mod_id ORDER BY created_at DESC) AS row_rank FROM "comments" WHERE mod_id IN ($1,$2) AND hidden = false AND comments.status = 'published' AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL) AS ranked WHERE row_rank <= $3 ORDER BY mod_id, row_rank`)).
+		WithArgs(modA, modB, 3).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "row_rank"}).
+			AddRow(uuid.NewString(), modA, "63b2dff9e834e550f0e50e66", "Good Job!", 1).
+			AddRow(uuid.NewString(), modA, "63b2dff9e834e550f0e50e66", "Nice", 2).
+			AddRow(uuid.NewString(), modB, "63b2dff9e834e550f0e50e66", "Broken", 1))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	grouped, err := repo.SearchByModIDs(context.Background(), []string{modA, modB}, 3, Viewer{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(grouped[modA]), 2)
+	assert.Equal(t, len(grouped[modB]), 1)
+}
+
+// will test get page of comments by user id
+func TestRepositorySearchByUserID(t *testing.T) {
+	// Arrange
+	var userID, modID = "63b2dff9e834e550f0e50e66", uuid.NewString()
+	var viewerID = "63b2dff9e834e550f0e50e67"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE user_id = $1 AND hidden = false AND mod_id = $2 AND (comments.status = 'published' OR (comments.status = 'pending' AND comments.user_id = $3)) AND comments.user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(userID, modID, viewerID, viewerID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(21))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE user_id = $1 AND hidden = false AND mod_id = $2 AND (comments.status = 'published' OR (comments.status = 'pending' AND comments.user_id = $3)) AND comments.user_id NOT IN (SELECT blocked_id FROM blocks WHERE blocker_id = $4) AND "comments"."deleted_at" IS NULL ORDER BY created_at DESC LIMIT 20 OFFSET 20`)).
+		WithArgs(userID, modID, viewerID, viewerID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(uuid.NewString(), modID, userID, "Good Job!"))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, count, err := repo.SearchByUserID(context.Background(), userID, modID, models.NewPagination(2, 0), Viewer{UserID: viewerID, Moderator: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(21))
+	assert.Equal(t, len(l), 1)
+}
+
+// will test get comment by id
+func TestRepositoryFindByID(t *testing.T) {
+	// Arrange
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL ORDER BY "comments"."id" LIMIT 1`)).
+		WithArgs(commentID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text"}).
+			AddRow(commentID, uuid.NewString(), "63b2dff9e834e550f0e50e66", "Good Job!"))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	comment, err := repo.FindByID(context.Background(), commentID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, comment.ID, commentID)
+}
+
 // will test insert comment
 func TestRepositoryInsert(t *testing.T) {
 	// Arrange
@@ -70,7 +228,7 @@ func TestRepositoryInsert(t *testing.T) {
 	db, mock := NewMock()
 
 	mock.ExpectBegin()
-	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id"`)).
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6) RETURNING "id","user_id","text"`)).
 		WithArgs(AnyTime{}, AnyTime{}, nil, comment.ModID, comment.UserID, comment.Text).
 		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(newId))
 	mock.ExpectCommit()
@@ -78,38 +236,305 @@ func TestRepositoryInsert(t *testing.T) {
 	repo := NewMockRepository(db)
 
 	// Act
-	err := repo.Save(comment)
+	err := repo.Create(context.Background(), comment)
 
 	// Assert
 	assert.NoError(t, err)
 	assert.Equal(t, comment.ID, newId.String())
 }
 
-// will test update comment
-func TestRepositoryUpdate(t *testing.T) {
+// will test create comment with an id inserts instead of overwriting
+func TestRepositoryCreateWithID(t *testing.T) {
 	// Arrange
 	comment := &models.Comment{
 		ID:     uuid.NewString(),
 		ModID:  uuid.NewString(),
 		UserID: "63b2dff9e834e550f0e50e66",
-		Text:   "dLooks Nice",
+		Text:   "Looks Nice",
 	}
 
 	db, mock := NewMock()
 
 	mock.ExpectBegin()
-	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "created_at"=$1,"updated_at"=$2,"deleted_at"=$3,"mod_id"=$4,"user_id"=$5,"text"=$6 WHERE "comments"."deleted_at" IS NULL AND "id" = $7`)).
-		WithArgs(AnyTime{}, AnyTime{}, nil, comment.ModID, comment.UserID, comment.Text, comment.ID).
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","updated_at","deleted_at","mod_id","id","user_id","text") VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING "id","user_id","text"`)).
+		WithArgs(AnyTime{}, AnyTime{}, nil, comment.ModID, comment.ID, comment.UserID, comment.Text).
+		WillReturnError(errors.New("duplicate key value violates unique constraint \"comments_pkey\""))
+	mock.ExpectRollback()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Create(context.Background(), comment)
+
+	// Assert
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update comment
+func TestRepositoryUpdate(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), Version: 1}
+
+	db, mock := NewMock()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"version"=version + 1,"updated_at"=$2 WHERE (id = $3 AND version = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs("dLooks Nice", AnyTime{}, comment.ID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Update(context.Background(), comment, map[string]interface{}{"text": "dLooks Nice"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, comment.Version, int64(2))
+}
+
+// will test an update never writes the hidden column
+func TestRepositoryUpdateKeepsHidden(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), Version: 1, Hidden: true}
+
+	db, mock := NewMock()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"version"=version + 1,"updated_at"=$2 WHERE (id = $3 AND version = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs("dLooks Nice", AnyTime{}, comment.ID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Update(context.Background(), comment, map[string]interface{}{"text": "dLooks Nice", "hidden": false})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test update of an unknown comment
+func TestRepositoryUpdateNotFound(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), Version: 1}
+
+	db, mock := NewMock()
+
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"version"=version + 1,"updated_at"=$2 WHERE (id = $3 AND version = $4) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs("dLooks Nice", AnyTime{}, comment.ID, 1).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE id = $1 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(comment.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Update(context.Background(), comment, map[string]interface{}{"text": "dLooks Nice"})
+
+	// Assert
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// will test replacing the mentions of a comment
+func TestRepositorySyncMentions(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), ModID: uuid.NewString(), UserID: "author"}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "user_id" FROM "mentions" WHERE comment_id = $1`)).
+		WithArgs(comment.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"user_id"}).AddRow("kept").AddRow("removed"))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "mentions" WHERE comment_id = $1 AND user_id IN ($2)`)).
+		WithArgs(comment.ID, "removed").
 		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "mentions" ("comment_id","mod_id","author_id","user_id","created_at") VALUES ($1,$2,$3,$4,$5) RETURNING "id"`)).
+		WithArgs(comment.ID, comment.ModID, comment.UserID, "added", AnyTime{}).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.New()))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	added, err := repo.SyncMentions(context.Background(), comment, []string{"kept", "added"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(added), 1)
+	assert.Equal(t, added[0].UserID, "added")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test blocking a user twice
+func TestRepositoryBlock(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blocks" ("blocker_id","blocked_id","created_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`)).
+		WithArgs("blocker", "blocked", AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Block(context.Background(), "blocker", "blocked")
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test finding the users that blocked an author
+func TestRepositorySearchBlockers(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "blocker_id" FROM "blocks" WHERE blocked_id = $1 AND blocker_id IN ($2,$3)`)).
+		WithArgs("author", "a", "b").
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id"}).AddRow("b"))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	blockers, err := repo.SearchBlockers(context.Background(), "author", []string{"a", "b"})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, blockers, []string{"b"})
+}
+
+// will test get page of the comments waiting for review of a mod
+func TestRepositorySearchPending(t *testing.T) {
+	// Arrange
+	var modID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE status = $1 AND mod_id = $2 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(models.StatusPending, modID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE status = $1 AND mod_id = $2 AND "comments"."deleted_at" IS NULL ORDER BY created_at ASC LIMIT 20`)).
+		WithArgs(models.StatusPending, modID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "Status"}).
+			AddRow(uuid.NewString(), modID, "63b2dff9e834e550f0e50e66", "Good Job!", models.StatusPending))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, count, err := repo.SearchPending(context.Background(), modID, models.NewPagination(1, 0), Viewer{Moderator: true})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, count, int64(1))
+	assert.Equal(t, l[0].Status, models.StatusPending)
+}
+
+// will test the pending comments a mod owner sees leave out shadow-banned users
+func TestRepositorySearchPendingForOwner(t *testing.T) {
+	// Arrange
+	var modID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "comments" WHERE status = $1 AND mod_id = $2 AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(models.StatusPending, modID).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE status = $1 AND mod_id = $2 AND comments.user_id NOT IN (SELECT user_id FROM shadow_bans) AND "comments"."deleted_at" IS NULL ORDER BY created_at ASC LIMIT 20`)).
+		WithArgs(models.StatusPending, modID).
+		WillReturnRows(sqlmock.NewRows([]string{"ID"}))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	_, count, err := repo.SearchPending(context.Background(), modID, models.NewPagination(1, 0), Viewer{})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, count, int64(0))
+}
+
+// will test reading the reviewed texts labelled as spam or ham
+func TestRepositorySearchTrainingSet(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT comments.text AS text, reviews.status = $1 AS spam FROM "reviews" JOIN comments ON comments.id = reviews.comment_id`)).
+		WithArgs(models.StatusRejected).
+		WillReturnRows(sqlmock.NewRows([]string{"text", "spam"}).AddRow("cheap mods", true).AddRow("Good Job!", false))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, err := repo.SearchTrainingSet(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(l), 2)
+	assert.True(t, l[0].Spam)
+	assert.False(t, l[1].Spam)
+}
+
+// will test pinning a comment in front of the existing pins
+func TestRepositoryPin(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), ModID: uuid.NewString()}
+	existingID := uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).
+		WithArgs(comment.ModID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(comment.ModID).
+		WillReturnRows(sqlmock.NewRows([]string{"comment_id", "mod_id", "position", "pinned_by"}).AddRow(existingID, comment.ModID, 1, "mod-1"))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "pins" ("comment_id","mod_id","position","pinned_by","created_at") VALUES ($1,$2,$3,$4,$5),($6,$7,$8,$9,$10) ON CONFLICT ("comment_id") DO UPDATE SET "position"="excluded"."position"`)).
+		WithArgs(comment.ID, comment.ModID, 1, "mod-2", AnyTime{}, existingID, comment.ModID, 2, "mod-1", AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 2))
 	mock.ExpectCommit()
 
 	repo := NewMockRepository(db)
 
 	// Act
-	err := repo.Save(comment)
+	err := repo.Pin(context.Background(), comment, 1, "mod-2")
 
 	// Assert
 	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test pinning a comment on a mod with the maximum number of pins
+func TestRepositoryPinLimitReached(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString(), ModID: uuid.NewString()}
+
+	db, mock := NewMock()
+	rows := sqlmock.NewRows([]string{"comment_id", "mod_id", "position"})
+	for i := 1; i <= models.MaxPinsPerMod; i++ {
+		rows.AddRow(uuid.NewString(), comment.ModID, i)
+	}
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock(hashtext($1))`)).
+		WithArgs(comment.ModID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "pins" WHERE mod_id = $1 ORDER BY position`)).
+		WithArgs(comment.ModID).
+		WillReturnRows(rows)
+	mock.ExpectRollback()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Pin(context.Background(), comment, 0, "mod-1")
+
+	// Assert
+	assert.ErrorIs(t, err, ErrPinLimitReached)
 }
 
 type AnyTime struct{}
@@ -119,3 +544,823 @@ func (a AnyTime) Match(v driver.Value) bool {
 	_, ok := v.(time.Time)
 	return ok
 }
+
+// will test get all by user id
+func TestRepositorySearchAllByUserID(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE user_id = $1 ORDER BY "comments"."id" LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.
+			NewRows([]string{"ID", "ModID", "UserID", "Text", "DeletedAt"}).
+			AddRow(uuid.New().String(), uuid.New().String(), userID, "Good Job!", nil).
+			AddRow(uuid.New().String(), uuid.New().String(), userID, "Removed", time.Now()))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	l, err := repo.SearchAllByUserID(context.Background(), userID)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, len(l), 2)
+	assert.True(t, l[1].DeletedAt.Valid)
+}
+
+// Expect the rows of a user outside its comments to be erased
+func expectEraseUserRecords(mock sqlmock.Sqlmock, userID string, anonymise bool) {
+	mock.ExpectBegin()
+	if anonymise {
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "mentions" SET "author_id"=$1 WHERE author_id = $2`)).
+			WithArgs(models.TombstoneUserID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "reviews" SET "user_id"=$1 WHERE user_id = $2`)).
+			WithArgs(models.TombstoneUserID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	} else {
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "mentions" WHERE author_id = $1`)).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+		mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "reviews" WHERE user_id = $1`)).
+			WithArgs(userID).
+			WillReturnResult(sqlmock.NewResult(0, 1))
+	}
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "mentions" WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "blocks" WHERE blocker_id = $1 OR blocked_id = $2`)).
+		WithArgs(userID, userID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "shadow_bans" WHERE user_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "idempotency_keys" WHERE caller_id = $1`)).
+		WithArgs(userID).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	for _, moderated := range [][2]string{{"reviews", "reviewed_by"}, {"shadow_bans", "banned_by"}, {"pins", "pinned_by"}, {"moderation_settings", "updated_by"}} {
+		table, column := moderated[0], moderated[1]
+		mock.ExpectExec(regexp.QuoteMeta(`UPDATE "`+table+`" SET "`+column+`"=$1 WHERE `+column+` = $2`)).
+			WithArgs(models.TombstoneUserID, userID).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+}
+
+// will test anonymise comments of user
+func TestRepositoryEraseByUserIDAnonymise(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(commentID))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "text"=$1,"user_id"=$2,"updated_at"=$3 WHERE id IN ($4)`)).
+		WithArgs("", models.TombstoneUserID, AnyTime{}, commentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectEraseUserRecords(mock, userID, true)
+
+	repo := NewMockRepository(db)
+
+	// Act
+	affected, err := repo.EraseByUserID(context.Background(), userID, true, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, affected, int64(1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test hard delete comments of user
+func TestRepositoryEraseByUserIDHardDelete(t *testing.T) {
+	// Arrange
+	var userID = "63b2dff9e834e550f0e50e66"
+	var commentID = uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(commentID))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "comments" WHERE id IN ($1)`)).
+		WithArgs(commentID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE user_id = $1 LIMIT 500`)).
+		WithArgs(userID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+	expectEraseUserRecords(mock, userID, false)
+
+	repo := NewMockRepository(db)
+
+	// Act
+	affected, err := repo.EraseByUserID(context.Background(), userID, false, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, affected, int64(1))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test restoring a deleted comment
+func TestRepositoryRestore(t *testing.T) {
+	// Arrange
+	id := uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+		WithArgs(nil, AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Restore(context.Background(), id)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test restoring a comment that is not deleted
+func TestRepositoryRestoreNotFound(t *testing.T) {
+	// Arrange
+	id := uuid.NewString()
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "deleted_at"=$1,"updated_at"=$2 WHERE id = $3 AND deleted_at IS NOT NULL`)).
+		WithArgs(nil, AnyTime{}, id).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Restore(context.Background(), id)
+
+	// Assert
+	assert.ErrorIs(t, err, gorm.ErrRecordNotFound)
+}
+
+// will test purging comments deleted before the retention cutoff
+func TestRepositoryPurgeDeleted(t *testing.T) {
+	// Arrange
+	before := time.Now().Add(-time.Hour)
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`DELETE FROM "comments" WHERE id IN (SELECT "id" FROM "comments" WHERE deleted_at < $1 LIMIT 500)`)).
+		WithArgs(before).
+		WillReturnResult(sqlmock.NewResult(0, 2))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	purged, err := repo.PurgeDeleted(context.Background(), before)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, int64(2), purged)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test streaming continues after the last comment of each batch
+func TestRepositoryStreamComments(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	first := uuid.NewString()
+	created := time.Now().Add(-time.Hour)
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE mod_id = $1 AND "comments"."deleted_at" IS NULL ORDER BY created_at, id LIMIT 500`)).
+		WithArgs(modID).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "mod_id", "created_at"}).AddRow(first, modID, created))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE (created_at, id) > ($1, $2) AND mod_id = $3 AND "comments"."deleted_at" IS NULL ORDER BY created_at, id LIMIT 500`)).
+		WithArgs(created, first, modID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	var streamed []*models.Comment
+	err := repo.StreamComments(context.Background(), models.CommentFilter{ModID: modID}, func(comments []*models.Comment) error {
+		streamed = append(streamed, comments...)
+		return nil
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Len(t, streamed, 1)
+	assert.Equal(t, first, streamed[0].ID)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test importing skips comments whose id exists
+func TestRepositoryImportCommentsSkip(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	existing := &models.Comment{ID: uuid.NewString(), ModID: modID, UserID: "user", Text: "old", Status: models.StatusPublished, CreatedAt: created, UpdatedAt: created}
+	imported := &models.Comment{ID: uuid.NewString(), ModID: modID, UserID: "user", Text: "new", Status: models.StatusPublished, CreatedAt: created, UpdatedAt: created}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE id IN ($1,$2)`)).
+		WithArgs(existing.ID, imported.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existing.ID))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","deleted_at","hidden","id","mod_id","spam_score","status","text","updated_at","user_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10)`)).
+		WithArgs(created, nil, false, imported.ID, modID, nil, models.StatusPublished, "new", created, "user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	result, err := repo.ImportComments(context.Background(), []*models.Comment{existing, imported}, false)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{existing.ID}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test importing overwrites comments whose id exists in one statement
+func TestRepositoryImportCommentsOverwrite(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	created := time.Date(2021, 3, 1, 12, 0, 0, 0, time.UTC)
+	existing := &models.Comment{ID: uuid.NewString(), ModID: modID, UserID: "user", Text: "old", Status: models.StatusPublished, CreatedAt: created, UpdatedAt: created}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "id" FROM "comments" WHERE id IN ($1)`)).
+		WithArgs(existing.ID).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(existing.ID))
+	mock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "comments" ("created_at","deleted_at","hidden","id","mod_id","spam_score","status","text","updated_at","user_id") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10) ON CONFLICT ("id") DO UPDATE SET "mod_id"="excluded"."mod_id","user_id"="excluded"."user_id","text"="excluded"."text","status"="excluded"."status","hidden"="excluded"."hidden","created_at"="excluded"."created_at","updated_at"="excluded"."updated_at","deleted_at"="excluded"."deleted_at","version"="comments"."version" + 1`)).
+		WithArgs(created, nil, false, existing.ID, modID, nil, models.StatusPublished, "old", created, "user").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	result, err := repo.ImportComments(context.Background(), []*models.Comment{existing}, true)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, []string{existing.ID}, result)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test streaming includes deleted comments when the filter asks for them
+func TestRepositoryStreamCommentsIncludeDeleted(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "comments" WHERE user_id = $1 ORDER BY created_at, id LIMIT 500`)).
+		WithArgs("user").
+		WillReturnRows(sqlmock.NewRows([]string{"id"}))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.StreamComments(context.Background(), models.CommentFilter{UserID: "user", IncludeDeleted: true}, func(comments []*models.Comment) error {
+		return nil
+	})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func NewMockReplicatedRepository(primary gorm.ConnPool, replica gorm.ConnPool, window time.Duration) *postgresRepository {
+	primaryDB, err := gorm.Open(postgres.New(postgres.Config{Conn: primary}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+	replicaDB, err := gorm.Open(postgres.New(postgres.Config{Conn: replica}), &gorm.Config{})
+	if err != nil {
+		log.Fatalf("an error '%s' was not expected when opening a stub database connection", err)
+	}
+
+	repo := NewRepository(primaryDB, WithReplicas(replicaDB), WithReadYourWrites(window))
+	repo.replicas.healthy[0].Store(true)
+	return repo
+}
+
+func expectSearchBlocked(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM "blocks" WHERE blocker_id = $1`)).
+		WithArgs("blocker").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "blocks" WHERE blocker_id = $1 ORDER BY created_at DESC LIMIT 10`)).
+		WithArgs("blocker").
+		WillReturnRows(sqlmock.NewRows([]string{"blocker_id", "blocked_id"}))
+}
+
+// will test listings are read from a healthy replica
+func TestRepositoryReadFromReplica(t *testing.T) {
+	// Arrange
+	primary, primaryMock := NewMock()
+	replica, replicaMock := NewMock()
+	expectSearchBlocked(replicaMock)
+
+	repo := NewMockReplicatedRepository(primary, replica, time.Minute)
+
+	// Act
+	_, _, err := repo.SearchBlocked(context.Background(), "blocker", models.Pagination{Page: 1, Size: 10})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+}
+
+// will test listings of a user that just wrote are read from the primary
+func TestRepositoryReadYourWrites(t *testing.T) {
+	// Arrange
+	primary, primaryMock := NewMock()
+	replica, replicaMock := NewMock()
+	primaryMock.ExpectBegin()
+	primaryMock.ExpectExec(regexp.QuoteMeta(`INSERT INTO "blocks" ("blocker_id","blocked_id","created_at") VALUES ($1,$2,$3) ON CONFLICT DO NOTHING`)).
+		WithArgs("blocker", "blocked", AnyTime{}).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	primaryMock.ExpectCommit()
+	expectSearchBlocked(primaryMock)
+
+	repo := NewMockReplicatedRepository(primary, replica, time.Minute)
+	ctx := WithUser(context.Background(), "blocker")
+
+	// Act
+	err := repo.Block(ctx, "blocker", "blocked")
+	_, _, searchErr := repo.SearchBlocked(ctx, "blocker", models.Pagination{Page: 1, Size: 10})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, searchErr)
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+// will test listings fall back to the primary without a healthy replica
+func TestRepositoryUnhealthyReplica(t *testing.T) {
+	// Arrange
+	primary, primaryMock := NewMock()
+	replica, replicaMock := NewMock()
+	expectSearchBlocked(primaryMock)
+
+	repo := NewMockReplicatedRepository(primary, replica, time.Minute)
+	repo.replicas.healthy[0].Store(false)
+
+	// Act
+	_, _, err := repo.SearchBlocked(context.Background(), "blocker", models.Pagination{Page: 1, Size: 10})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, primaryMock.ExpectationsWereMet())
+	assert.NoError(t, replicaMock.ExpectationsWereMet())
+}
+
+// Repository that counts listings and blocks them until release is closed
+type countingRepository struct {
+	ModRepository
+	loads   atomic.Int32
+	release chan struct{}
+}
+
+func (r *countingRepository) SearchByModID(ctx context.Context, modID string, options ListOptions) ([]*models.Comment, error) {
+	r.loads.Add(1)
+	<-r.release
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	return []*models.Comment{{ID: uuid.NewString(), ModID: modID}}, nil
+}
+
+func (r *countingRepository) Create(ctx context.Context, comment *models.Comment) error {
+	return nil
+}
+
+func (r *countingRepository) Transaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	return fn(ctx)
+}
+
+// Shared cache fake that keeps listings in a map
+type fakeCache struct {
+	mu       sync.Mutex
+	listings map[string]map[string][]*models.Comment
+}
+
+func (c *fakeCache) Get(ctx context.Context, modID string, key string) ([]*models.Comment, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	comments, ok := c.listings[modID][key]
+	return comments, ok
+}
+
+func (c *fakeCache) Set(ctx context.Context, modID string, key string, comments []*models.Comment) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.listings[modID] == nil {
+		c.listings[modID] = map[string][]*models.Comment{}
+	}
+	c.listings[modID][key] = comments
+}
+
+func (c *fakeCache) Invalidate(ctx context.Context, modID string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.listings, modID)
+}
+
+func (c *fakeCache) Purge(ctx context.Context) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.listings = map[string]map[string][]*models.Comment{}
+}
+
+func newCountingRepository() *countingRepository {
+	release := make(chan struct{})
+	close(release)
+	return &countingRepository{release: release}
+}
+
+// will test listings are served from the cache until a comment of the mod is created
+func TestCachedRepositoryInvalidation(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	inner := newCountingRepository()
+	repo := NewCachedRepository(inner, &fakeCache{listings: map[string]map[string][]*models.Comment{}})
+
+	// Act
+	first, _ := repo.SearchByModID(context.Background(), modID, ListOptions{})
+	cached, _ := repo.SearchByModID(context.Background(), modID, ListOptions{})
+	repo.Create(context.Background(), &models.Comment{ModID: modID})
+	repo.SearchByModID(context.Background(), modID, ListOptions{})
+
+	// Assert
+	assert.Equal(t, cached[0].ID, first[0].ID)
+	assert.Equal(t, inner.loads.Load(), int32(2))
+	assert.Equal(t, repo.Stats(), CacheStats{Hits: 1, Misses: 2})
+}
+
+// will test a listing cached while a transaction is open is dropped once it ends
+func TestCachedRepositoryTransaction(t *testing.T) {
+	// Arrange
+	modID := uuid.NewString()
+	inner := newCountingRepository()
+	repo := NewCachedRepository(inner, &fakeCache{listings: map[string]map[string][]*models.Comment{}})
+
+	// Act
+	repo.SearchByModID(context.Background(), modID, ListOptions{})
+	repo.Transaction(context.Background(), func(ctx context.Context) error {
+		repo.Create(ctx, &models.Comment{ModID: modID})
+		// Read by another request before the commit
+		repo.SearchByModID(context.Background(), modID, ListOptions{})
+		return nil
+	})
+	repo.SearchByModID(context.Background(), modID, ListOptions{})
+
+	// Assert
+	assert.Equal(t, inner.loads.Load(), int32(3))
+}
+
+// will test listings of signed in viewers are never cached
+func TestCachedRepositorySignedInViewer(t *testing.T) {
+	// Arrange
+	inner := newCountingRepository()
+	repo := NewCachedRepository(inner, NewLRUCache(10, time.Minute))
+	options := ListOptions{Viewer: Viewer{UserID: "63b2dff9e834e550f0e50e66"}}
+
+	// Act
+	repo.SearchByModID(context.Background(), "mod", options)
+	repo.SearchByModID(context.Background(), "mod", options)
+
+	// Assert
+	assert.Equal(t, inner.loads.Load(), int32(2))
+}
+
+// will test concurrent misses of a listing load it once
+func TestCachedRepositoryCoalescing(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{release: make(chan struct{})}
+	repo := NewCachedRepository(inner, NewLRUCache(10, time.Minute))
+
+	// Act
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			repo.SearchByModID(context.Background(), "mod", ListOptions{})
+		}()
+	}
+	for repo.Stats().Misses < 10 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	assert.Equal(t, inner.loads.Load(), int32(1))
+	assert.Equal(t, repo.Stats().Coalesced, uint64(10))
+}
+
+// will test a shared load is not cancelled with the caller that started it
+func TestCachedRepositoryCoalescingCancelled(t *testing.T) {
+	// Arrange
+	inner := &countingRepository{release: make(chan struct{})}
+	repo := NewCachedRepository(inner, NewLRUCache(10, time.Minute))
+	ctx, cancel := context.WithCancel(context.Background())
+
+	// Act
+	var wg sync.WaitGroup
+	var first, second error
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		_, first = repo.SearchByModID(ctx, "mod", ListOptions{})
+	}()
+	for inner.loads.Load() < 1 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() {
+		defer wg.Done()
+		_, second = repo.SearchByModID(context.Background(), "mod", ListOptions{})
+	}()
+	for repo.Stats().Misses < 2 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+	close(inner.release)
+	wg.Wait()
+
+	// Assert
+	assert.NoError(t, first)
+	assert.NoError(t, second)
+	assert.Equal(t, inner.loads.Load(), int32(1))
+}
+
+// will test the least recently used and expired listings leave the cache
+func TestLRUCacheEviction(t *testing.T) {
+	// Arrange
+	now := time.Now()
+	cache := NewLRUCache(2, time.Minute)
+	cache.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Act
+	cache.Set(ctx, "a", "newest", nil)
+	cache.Set(ctx, "b", "newest", nil)
+	cache.Get(ctx, "a", "newest")
+	cache.Set(ctx, "c", "newest", nil)
+	_, a := cache.Get(ctx, "a", "newest")
+	_, b := cache.Get(ctx, "b", "newest")
+	now = now.Add(2 * time.Minute)
+	_, c := cache.Get(ctx, "c", "newest")
+
+	// Assert
+	assert.True(t, a)
+	assert.False(t, b)
+	assert.False(t, c)
+}
+
+// will test an audit entry is numbered and hashed after the last entry
+func TestAuditChainsToLastEntry(t *testing.T) {
+	// Arrange
+	entry := &models.AuditLog{Actor: "moderator-1", Action: models.AuditActionHide, Target: uuid.NewString()}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(auditLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "sequence","hash" FROM "audit_logs" WHERE hash <> '' ORDER BY sequence DESC LIMIT 1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "hash"}).AddRow(4, "ab"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_logs" ("sequence","actor","action","target","before","after","detail","request_id","client_ip","created_at","prev_hash","hash") VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12) RETURNING "id"`)).
+		WithArgs(5, entry.Actor, entry.Action, entry.Target, "", "", "", "", "", AnyTime{}, "ab", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewString()))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Audit(context.Background(), entry)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, entry.Sequence, int64(5))
+	assert.Equal(t, entry.Hash, entry.ComputeHash())
+}
+
+// will test the entries of one append are chained to each other
+func TestAuditChainsEntriesOfOneAppend(t *testing.T) {
+	// Arrange
+	first := &models.AuditLog{Actor: "moderator-1", Action: models.AuditActionBulkHide, Target: uuid.NewString()}
+	second := &models.AuditLog{Actor: "moderator-1", Action: models.AuditActionBulkHide, Target: uuid.NewString()}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(auditLockKey).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT "sequence","hash" FROM "audit_logs" WHERE hash <> '' ORDER BY sequence DESC LIMIT 1`)).
+		WillReturnRows(sqlmock.NewRows([]string{"sequence", "hash"}).AddRow(4, "ab"))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_logs"`)).
+		WithArgs(5, first.Actor, first.Action, first.Target, "", "", "", "", "", AnyTime{}, "ab", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewString()))
+	mock.ExpectQuery(regexp.QuoteMeta(`INSERT INTO "audit_logs"`)).
+		WithArgs(6, second.Actor, second.Action, second.Target, "", "", "", "", "", AnyTime{}, sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"id"}).AddRow(uuid.NewString()))
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Audit(context.Background(), first, second)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, second.Sequence, int64(6))
+	assert.Equal(t, second.PrevHash, first.Hash)
+}
+
+// will test a change is rolled back when its audit entry can not be written
+func TestRepositoryTransactionRollsBack(t *testing.T) {
+	// Arrange
+	comment := &models.Comment{ID: uuid.NewString()}
+
+	db, mock := NewMock()
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`UPDATE "comments" SET "hidden"=$1,"updated_at"=$2 WHERE id = $3 AND "comments"."deleted_at" IS NULL`)).
+		WithArgs(false, AnyTime{}, comment.ID).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(regexp.QuoteMeta(`SELECT pg_advisory_xact_lock($1)`)).
+		WithArgs(auditLockKey).
+		WillReturnError(errors.New("connection reset"))
+	mock.ExpectRollback()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := repo.Transaction(context.Background(), func(ctx context.Context) error {
+		if err := repo.SetHidden(ctx, comment, false); err != nil {
+			return err
+		}
+		return repo.Audit(ctx, &models.AuditLog{Actor: "moderator-1", Action: models.AuditActionUnhide, Target: comment.ID})
+	})
+
+	// Assert
+	assert.Error(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// Returns a chain of audit entries starting at the first sequence
+func auditChain(n int) []*models.AuditLog {
+	entries := make([]*models.AuditLog, n)
+	prev := ""
+	for i := range entries {
+		entries[i] = &models.AuditLog{
+			ID:        uuid.NewString(),
+			Sequence:  int64(i + 1),
+			Actor:     "moderator-1",
+			Action:    models.AuditActionDelete,
+			Target:    uuid.NewString(),
+			CreatedAt: time.Date(2026, 1, 1, 0, i, 0, 0, time.UTC),
+			PrevHash:  prev,
+		}
+		entries[i].Hash = entries[i].ComputeHash()
+		prev = entries[i].Hash
+	}
+	return entries
+}
+
+func auditRows(entries []*models.AuditLog) *sqlmock.Rows {
+	rows := sqlmock.NewRows([]string{"id", "sequence", "actor", "action", "target", "before", "after", "detail", "request_id", "client_ip", "created_at", "prev_hash", "hash"})
+	for _, e := range entries {
+		rows.AddRow(e.ID, e.Sequence, e.Actor, e.Action, e.Target, e.Before, e.After, e.Detail, e.RequestID, e.ClientIP, e.CreatedAt, e.PrevHash, e.Hash)
+	}
+	return rows
+}
+
+// will test verify an intact audit log
+func TestVerifyAuditLog(t *testing.T) {
+	// Arrange
+	entries := auditChain(3)
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_logs" WHERE sequence > $1 ORDER BY sequence LIMIT 500`)).
+		WithArgs(0).
+		WillReturnRows(auditRows(entries))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_logs" WHERE sequence > $1 ORDER BY sequence LIMIT 500`)).
+		WithArgs(3).
+		WillReturnRows(auditRows(nil))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	result, err := repo.VerifyAuditLog(context.Background())
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, result.Entries, int64(3))
+	assert.Equal(t, result.LastHash, entries[2].Hash)
+	assert.Zero(t, result.BrokenAt)
+}
+
+// will test verify reports a changed and a removed audit entry
+func TestVerifyAuditLogTampered(t *testing.T) {
+	// Arrange
+	changed := auditChain(3)
+	changed[1].Detail = "reason=\"none\""
+	removed := auditChain(3)
+	removed = append(removed[:1], removed[2])
+
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_logs" WHERE sequence > $1 ORDER BY sequence LIMIT 500`)).
+		WithArgs(0).
+		WillReturnRows(auditRows(changed))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT * FROM "audit_logs" WHERE sequence > $1 ORDER BY sequence LIMIT 500`)).
+		WithArgs(0).
+		WillReturnRows(auditRows(removed))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	changedResult, err := repo.VerifyAuditLog(context.Background())
+	assert.NoError(t, err)
+	removedResult, err := repo.VerifyAuditLog(context.Background())
+	assert.NoError(t, err)
+
+	// Assert
+	assert.NoError(t, mock.ExpectationsWereMet())
+	assert.Equal(t, changedResult.BrokenAt, int64(2))
+	assert.Equal(t, changedResult.Reason, "hash does not match the entry")
+	assert.Equal(t, removedResult.BrokenAt, int64(2))
+	assert.Equal(t, removedResult.Reason, "entry 2 is missing")
+}
+
+// will test the comment id migration is skipped once the primary key exists
+func TestRepositoryMigrateCommentIDsKeyed(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM information_schema.tables WHERE table_schema = CURRENT_SCHEMA() AND table_name = $1 AND table_type = $2`)).
+		WithArgs("comments", "BASE TABLE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM pg_constraint WHERE conrelid = 'comments'::regclass AND contype = 'p'`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := migrateCommentIDs(repo.db)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// will test the comment id migration of a table without primary key
+func TestRepositoryMigrateCommentIDs(t *testing.T) {
+	// Arrange
+	db, mock := NewMock()
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM information_schema.tables WHERE table_schema = CURRENT_SCHEMA() AND table_name = $1 AND table_type = $2`)).
+		WithArgs("comments", "BASE TABLE").
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(1))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT count(*) FROM pg_constraint WHERE conrelid = 'comments'::regclass AND contype = 'p'`)).
+		WillReturnRows(sqlmock.NewRows([]string{"count"}).AddRow(0))
+	mock.ExpectQuery(regexp.QuoteMeta(`SELECT data_type FROM information_schema.columns WHERE table_name = 'comments' AND column_name = 'id'`)).
+		WillReturnRows(sqlmock.NewRows([]string{"data_type"}).AddRow("text"))
+	mock.ExpectBegin()
+	mock.ExpectExec(regexp.QuoteMeta(`ALTER TABLE comments ALTER COLUMN id DROP DEFAULT, ALTER COLUMN id TYPE uuid USING id::uuid`)).
+		WillReturnResult(sqlmock.NewResult(0, 0))
+	for _, statement := range commentIDMigration {
+		mock.ExpectExec(regexp.QuoteMeta(statement)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+	}
+	mock.ExpectCommit()
+
+	repo := NewMockRepository(db)
+
+	// Act
+	err := migrateCommentIDs(repo.db)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,81 @@
+package repository
+
+import (
+	"context"
+	"errors"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrNotPending = errors.New("comment is not pending review")
+
+// Publishes or rejects a pending comment and records the decision
+func (p *postgresRepository) Review(ctx context.Context, review *models.Review) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Model(&models.Comment{}).Where(`id = ? AND status = ?`, review.CommentID, models.StatusPending).Update("status", review.Status)
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return ErrNotPending
+		}
+		return tx.Create(review).Error
+	})
+}
+
+// Returns the number of comments of a user that moderators approved
+func (p *postgresRepository) CountApproved(ctx context.Context, userID string) (int64, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&models.Review{}).Where(`user_id = ? AND status = ?`, userID, models.StatusPublished).Count(&count).Error
+	return count, err
+}
+
+// Returns a page of the comments waiting for review, oldest first, optionally limited to one mod.
+// Mod owners do not see the comments of shadow-banned users, moderators do.
+func (p *postgresRepository) SearchPending(ctx context.Context, modID string, pagination models.Pagination, viewer Viewer) ([]*models.Comment, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.Comment{}).Where(`status = ?`, models.StatusPending)
+	if modID != "" {
+		query = query.Where(`mod_id = ?`, modID)
+	}
+	query = query.Scopes(viewer.shadowBans).Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var l []*models.Comment
+	err := query.Order(`created_at ASC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	return l, count, err
+}
+
+func (p *postgresRepository) IsPreModerated(ctx context.Context, modID string) (bool, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var settings []*models.ModerationSetting
+	err := db.Where(`mod_id = ?`, modID).Limit(1).Find(&settings).Error
+	if err != nil || len(settings) == 0 {
+		return false, err
+	}
+	return settings[0].PreModeration, nil
+}
+
+func (p *postgresRepository) SetPreModerated(ctx context.Context, setting *models.ModerationSetting) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "mod_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"pre_moderation", "updated_by", "updated_at"}),
+	}).Create(setting).Error
+}
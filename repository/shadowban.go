@@ -0,0 +1,60 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stores a shadow-ban, banning an already banned user replaces the reason
+func (p *postgresRepository) ShadowBan(ctx context.Context, ban *models.ShadowBan) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+	return db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"banned_by", "reason"}),
+	}).Create(ban).Error
+}
+
+// Lifts the shadow-ban of a user, returns gorm.ErrRecordNotFound when the user was not banned
+func (p *postgresRepository) LiftShadowBan(ctx context.Context, userID string) error {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	result := db.Where(`user_id = ?`, userID).Delete(&models.ShadowBan{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// Returns a page of the shadow-banned users, newest first
+func (p *postgresRepository) SearchShadowBans(ctx context.Context, pagination models.Pagination) ([]*models.ShadowBan, int64, error) {
+	db, cancel := p.replica(ctx)
+	defer cancel()
+
+	query := db.Model(&models.ShadowBan{}).Session(&gorm.Session{})
+
+	var count int64
+	if err := query.Count(&count).Error; err != nil {
+		return nil, 0, err
+	}
+
+	var l []*models.ShadowBan
+	err := query.Order(`created_at DESC`).Offset(pagination.Offset()).Limit(pagination.Size).Find(&l).Error
+	return l, count, err
+}
+
+func (p *postgresRepository) IsShadowBanned(ctx context.Context, userID string) (bool, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&models.ShadowBan{}).Where(`user_id = ?`, userID).Count(&count).Error
+	return count > 0, err
+}
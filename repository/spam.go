@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"github.com/mxbikes/mxbikesclient.service.comment/models"
+)
+
+// Returns the number of comments a user wrote since a time
+func (p *postgresRepository) CountRecentByUserID(ctx context.Context, userID string, since time.Time) (int64, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&models.Comment{}).Where(`user_id = ? AND created_at >= ?`, userID, since).Count(&count).Error
+	return count, err
+}
+
+// Returns the number of comments of a user with the same text on other mods since a time
+func (p *postgresRepository) CountDuplicates(ctx context.Context, userID string, modID string, text string, since time.Time) (int64, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var count int64
+	err := db.Model(&models.Comment{}).
+		Where(`user_id = ? AND mod_id <> ? AND created_at >= ?`, userID, modID, since).
+		Where(`lower(text) = lower(?)`, text).
+		Count(&count).Error
+	return count, err
+}
+
+// Returns the text of every reviewed comment, rejected comments are labelled spam
+func (p *postgresRepository) SearchTrainingSet(ctx context.Context) ([]*models.LabeledText, error) {
+	db, cancel := p.session(ctx)
+	defer cancel()
+
+	var l []*models.LabeledText
+	err := db.Model(&models.Review{}).
+		Select(`comments.text AS text, reviews.status = ? AS spam`, models.StatusRejected).
+		Joins(`JOIN comments ON comments.id = reviews.comment_id`).
+		Scan(&l).Error
+	return l, err
+}
@@ -0,0 +1,133 @@
+package spam
+
+import (
+	"encoding/json"
+	"math"
+	"os"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Number of most decisive tokens combined into a probability
+const interestingTokens = 15
+
+// Tokens are runs of letters and digits, links are reduced to their host
+var (
+	tokenPattern = regexp.MustCompile(`[\p{L}\p{N}][\p{L}\p{N}'_-]*`)
+	hostPattern  = regexp.MustCompile(`(?i)(?:https?://|www\.)([a-z0-9.-]+)`)
+)
+
+// Model is a naive Bayes token model trained from moderator decisions
+type Model struct {
+	SpamDocuments int            `json:"spamDocuments"`
+	HamDocuments  int            `json:"hamDocuments"`
+	SpamTokens    map[string]int `json:"spamTokens"`
+	HamTokens     map[string]int `json:"hamTokens"`
+}
+
+func NewModel() *Model {
+	return &Model{SpamTokens: make(map[string]int), HamTokens: make(map[string]int)}
+}
+
+// Load a model written by Save
+func LoadModel(path string) (*Model, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	model := NewModel()
+	if err := json.Unmarshal(data, model); err != nil {
+		return nil, err
+	}
+	return model, nil
+}
+
+func (m *Model) Save(path string) error {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, data, 0o644)
+}
+
+// Train the model with one labelled text
+func (m *Model) Train(text string, spam bool) {
+	counts, documents := m.HamTokens, &m.HamDocuments
+	if spam {
+		counts, documents = m.SpamTokens, &m.SpamDocuments
+	}
+	*documents++
+	for _, token := range Tokenize(text) {
+		counts[token]++
+	}
+}
+
+// Return the probability that text is spam, 0.5 while the model knows nothing of both classes
+func (m *Model) Probability(text string) float64 {
+	if m.SpamDocuments == 0 || m.HamDocuments == 0 {
+		return 0.5
+	}
+
+	var probabilities []float64
+	for _, token := range Tokenize(text) {
+		probabilities = append(probabilities, m.tokenProbability(token))
+	}
+	if len(probabilities) == 0 {
+		return 0.5
+	}
+
+	// Only the tokens furthest from neutral decide
+	sort.Slice(probabilities, func(i, j int) bool {
+		return math.Abs(probabilities[i]-0.5) > math.Abs(probabilities[j]-0.5)
+	})
+	if len(probabilities) > interestingTokens {
+		probabilities = probabilities[:interestingTokens]
+	}
+
+	var spamLog, hamLog float64
+	for _, p := range probabilities {
+		spamLog += math.Log(p)
+		hamLog += math.Log(1 - p)
+	}
+	return 1 / (1 + math.Exp(hamLog-spamLog))
+}
+
+// Robinson's smoothed token probability, unknown tokens are neutral
+func (m *Model) tokenProbability(token string) float64 {
+	const strength, neutral = 1.0, 0.5
+
+	spamFrequency := float64(m.SpamTokens[token]) / float64(m.SpamDocuments)
+	hamFrequency := float64(m.HamTokens[token]) / float64(m.HamDocuments)
+	seen := float64(m.SpamTokens[token] + m.HamTokens[token])
+	if seen == 0 {
+		return neutral
+	}
+
+	p := spamFrequency / (spamFrequency + hamFrequency)
+	p = (strength*neutral + seen*p) / (strength + seen)
+	return math.Min(math.Max(p, 0.01), 0.99)
+}
+
+// Tokenize returns the distinct lower case tokens of text, links become a host token
+func Tokenize(text string) []string {
+	var tokens []string
+	seen := make(map[string]bool)
+	add := func(token string) {
+		if !seen[token] {
+			seen[token] = true
+			tokens = append(tokens, token)
+		}
+	}
+
+	for _, match := range hostPattern.FindAllStringSubmatch(text, -1) {
+		add("host:" + strings.ToLower(strings.TrimRight(match[1], ".")))
+	}
+	for _, token := range tokenPattern.FindAllString(strings.ToLower(hostPattern.ReplaceAllString(text, " ")), -1) {
+		if n := len(token); n >= 2 && n <= 30 {
+			add(token)
+		}
+	}
+	return tokens
+}
@@ -0,0 +1,91 @@
+package spam
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func trainedModel() *Model {
+	return Train([]Sample{
+		{"cheap bike mods free download visit https://cheap-mods.example now", true},
+		{"free download cheap skins https://cheap-mods.example", true},
+		{"best cheap mods free at www.cheap-mods.example", true},
+		{"the rear suspension feels too soft on the big jumps", false},
+		{"great track, the ruts are really well made", false},
+		{"crashes on load since the last beta, any fix?", false},
+	})
+}
+
+// will test tokenizing of text
+func TestTokenize(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected []string
+	}{
+		{"words", "Great track, great ruts!", []string{"great", "track", "ruts"}},
+		{"links", "see https://Cheap-Mods.example/free now", []string{"host:cheap-mods.example", "see", "free", "now"}},
+		{"short", "a b c", nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Tokenize(test.text))
+		})
+	}
+}
+
+// will test probability of spam and ham texts
+func TestModelProbability(t *testing.T) {
+	// Arrange
+	model := trainedModel()
+
+	// Act
+	spam := model.Probability("free cheap mods at https://cheap-mods.example")
+	ham := model.Probability("the suspension is too soft since the beta")
+
+	// Assert
+	assert.Greater(t, spam, 0.9)
+	assert.Less(t, ham, 0.1)
+}
+
+// will test probability of an untrained model is neutral
+func TestModelProbabilityUntrained(t *testing.T) {
+	assert.Equal(t, 0.5, NewModel().Probability("free cheap mods"))
+}
+
+// will test saving and loading a model
+func TestModelSaveLoad(t *testing.T) {
+	// Arrange
+	model := trainedModel()
+	path := filepath.Join(t.TempDir(), "model.json")
+
+	// Act
+	err := model.Save(path)
+	loaded, loadErr := LoadModel(path)
+
+	// Assert
+	assert.NoError(t, err)
+	assert.NoError(t, loadErr)
+	assert.Equal(t, model, loaded)
+}
+
+// will test evaluating on held out samples
+func TestEvaluate(t *testing.T) {
+	// Arrange
+	var samples []Sample
+	for i := 0; i < 10; i++ {
+		samples = append(samples,
+			Sample{"free cheap mods download https://cheap-mods.example", true},
+			Sample{"the suspension feels great on this track", false})
+	}
+
+	// Act
+	report := Evaluate(samples, 5, 0.5)
+
+	// Assert
+	assert.Equal(t, report.Total(), 4)
+	assert.Equal(t, report.Accuracy(), 1.0)
+}
@@ -0,0 +1,81 @@
+package spam
+
+// Sample is a text labelled by a moderator decision
+type Sample struct {
+	Text string
+	Spam bool
+}
+
+// Report counts the outcomes of classifying held out samples
+type Report struct {
+	TruePositives  int
+	FalsePositives int
+	TrueNegatives  int
+	FalseNegatives int
+}
+
+func (r Report) Total() int {
+	return r.TruePositives + r.FalsePositives + r.TrueNegatives + r.FalseNegatives
+}
+
+func (r Report) Precision() float64 {
+	return ratio(r.TruePositives, r.TruePositives+r.FalsePositives)
+}
+
+func (r Report) Recall() float64 {
+	return ratio(r.TruePositives, r.TruePositives+r.FalseNegatives)
+}
+
+func (r Report) Accuracy() float64 {
+	return ratio(r.TruePositives+r.TrueNegatives, r.Total())
+}
+
+// Train returns a model trained on every sample
+func Train(samples []Sample) *Model {
+	model := NewModel()
+	for _, sample := range samples {
+		model.Train(sample.Text, sample.Spam)
+	}
+	return model
+}
+
+// Evaluate holds out every holdout-th sample, trains on the others and
+// classifies the held out samples as spam at or above threshold
+func Evaluate(samples []Sample, holdout int, threshold float64) Report {
+	if holdout < 2 {
+		holdout = 2
+	}
+
+	var training, testing []Sample
+	for i, sample := range samples {
+		if i%holdout == 0 {
+			testing = append(testing, sample)
+		} else {
+			training = append(training, sample)
+		}
+	}
+
+	model := Train(training)
+	var report Report
+	for _, sample := range testing {
+		predicted := model.Probability(sample.Text) >= threshold
+		switch {
+		case predicted && sample.Spam:
+			report.TruePositives++
+		case predicted && !sample.Spam:
+			report.FalsePositives++
+		case !predicted && sample.Spam:
+			report.FalseNegatives++
+		default:
+			report.TrueNegatives++
+		}
+	}
+	return report
+}
+
+func ratio(a int, b int) float64 {
+	if b == 0 {
+		return 0
+	}
+	return float64(a) / float64(b)
+}
@@ -0,0 +1,138 @@
+package spam
+
+import (
+	"context"
+	"math"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// Links are counted by their scheme or www prefix
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+
+// History gives the scorer access to what a user posted before
+type History interface {
+	// Number of comments of a user since a time
+	CountRecentByUserID(ctx context.Context, userID string, since time.Time) (int64, error)
+	// Number of comments of a user with the same text on other mods since a time
+	CountDuplicates(ctx context.Context, userID string, modID string, text string, since time.Time) (int64, error)
+}
+
+// Input is a comment about to be stored
+type Input struct {
+	UserID string
+	ModID  string
+	Text   string
+	// Creation time of the author's account, zero when unknown
+	AccountCreated time.Time
+}
+
+// Signals are the individual spam indications of a comment, each between 0 and 1
+type Signals struct {
+	LinkDensity float64
+	Duplicates  float64
+	AccountAge  float64
+	Velocity    float64
+	Tokens      float64
+}
+
+// Weights of the signals in the combined score, each between 0 and 1
+type Weights Signals
+
+// DefaultWeights let the token model and duplicates weigh most, a young account alone never decides
+var DefaultWeights = Weights{
+	LinkDensity: 0.6,
+	Duplicates:  0.7,
+	AccountAge:  0.3,
+	Velocity:    0.5,
+	Tokens:      0.9,
+}
+
+type Result struct {
+	Score   float64
+	Signals Signals
+}
+
+type Scorer struct {
+	history        History
+	model          *Model
+	weights        Weights
+	duplicateSince time.Duration
+	velocitySince  time.Duration
+	now            func() time.Time
+}
+
+// Return a scorer looking back in history, model may be nil to score without tokens
+func NewScorer(history History, model *Model) *Scorer {
+	return &Scorer{
+		history:        history,
+		model:          model,
+		weights:        DefaultWeights,
+		duplicateSince: 24 * time.Hour,
+		velocitySince:  10 * time.Minute,
+		now:            time.Now,
+	}
+}
+
+// Score combines the signals of a comment into a score between 0 (ham) and 1 (spam)
+func (s *Scorer) Score(ctx context.Context, input Input) (Result, error) {
+	now := s.now()
+	signals := Signals{
+		LinkDensity: LinkDensity(input.Text),
+		AccountAge:  accountAge(input.AccountCreated, now),
+	}
+
+	duplicates, err := s.history.CountDuplicates(ctx, input.UserID, input.ModID, input.Text, now.Add(-s.duplicateSince))
+	if err != nil {
+		return Result{}, err
+	}
+	signals.Duplicates = math.Min(1, float64(duplicates)/2)
+
+	recent, err := s.history.CountRecentByUserID(ctx, input.UserID, now.Add(-s.velocitySince))
+	if err != nil {
+		return Result{}, err
+	}
+	signals.Velocity = math.Min(1, math.Max(0, float64(recent-2))/5)
+
+	// A neutral model says nothing either way
+	if s.model != nil {
+		signals.Tokens = math.Max(0, s.model.Probability(input.Text)-0.5) * 2
+	}
+
+	return Result{Score: s.combine(signals), Signals: signals}, nil
+}
+
+// Weighted noisy-or, any single strong signal can make a comment spam
+func (s *Scorer) combine(signals Signals) float64 {
+	ham := 1.0
+	ham *= 1 - s.weights.LinkDensity*signals.LinkDensity
+	ham *= 1 - s.weights.Duplicates*signals.Duplicates
+	ham *= 1 - s.weights.AccountAge*signals.AccountAge
+	ham *= 1 - s.weights.Velocity*signals.Velocity
+	ham *= 1 - s.weights.Tokens*signals.Tokens
+	return 1 - ham
+}
+
+// LinkDensity is 1 for one link in every three words or more
+func LinkDensity(text string) float64 {
+	links := len(linkPattern.FindAllString(text, -1))
+	if links == 0 {
+		return 0
+	}
+	words := len(strings.Fields(text))
+	return math.Min(1, 3*float64(links)/float64(words))
+}
+
+func accountAge(created time.Time, now time.Time) float64 {
+	switch age := now.Sub(created); {
+	case created.IsZero():
+		return 0
+	case age < 24*time.Hour:
+		return 1
+	case age < 7*24*time.Hour:
+		return 0.5
+	default:
+		return 0
+	}
+}
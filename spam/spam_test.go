@@ -0,0 +1,82 @@
+package spam
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeHistory struct {
+	recent     int64
+	duplicates int64
+}
+
+func (h fakeHistory) CountRecentByUserID(ctx context.Context, userID string, since time.Time) (int64, error) {
+	return h.recent, nil
+}
+
+func (h fakeHistory) CountDuplicates(ctx context.Context, userID string, modID string, text string, since time.Time) (int64, error) {
+	return h.duplicates, nil
+}
+
+// will test link density of text
+func TestLinkDensity(t *testing.T) {
+	tests := []struct {
+		name     string
+		text     string
+		expected float64
+	}{
+		{"none", "Good Job!", 0},
+		{"dense", "https://a.example https://b.example", 1},
+		{"sparse", "the fix is described at www.mxbikes.example in the install notes section please", 0.25},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.InDelta(t, test.expected, LinkDensity(test.text), 0.001)
+		})
+	}
+}
+
+// will test scoring of an ordinary comment
+func TestScoreHam(t *testing.T) {
+	// Arrange
+	now := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	scorer := NewScorer(fakeHistory{recent: 1}, trainedModel())
+	scorer.now = func() time.Time { return now }
+
+	// Act
+	result, err := scorer.Score(context.Background(), Input{Text: "the rear suspension feels too soft", AccountCreated: now.AddDate(-1, 0, 0)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, DefaultThresholds.Verdict(result.Score), Accept)
+}
+
+// will test scoring of a spam comment from a new account posting across mods
+func TestScoreSpam(t *testing.T) {
+	// Arrange
+	now := time.Date(2023, 1, 10, 0, 0, 0, 0, time.UTC)
+	scorer := NewScorer(fakeHistory{recent: 8, duplicates: 3}, trainedModel())
+	scorer.now = func() time.Time { return now }
+
+	// Act
+	result, err := scorer.Score(context.Background(), Input{Text: "free cheap mods https://cheap-mods.example", AccountCreated: now.Add(-time.Hour)})
+
+	// Assert
+	assert.NoError(t, err)
+	assert.Equal(t, result.Signals.AccountAge, 1.0)
+	assert.Equal(t, result.Signals.Duplicates, 1.0)
+	assert.Equal(t, DefaultThresholds.Verdict(result.Score), Reject)
+}
+
+// will test verdicts of scores
+func TestVerdict(t *testing.T) {
+	thresholds := Thresholds{Review: 0.5, Reject: 0.9}
+
+	assert.Equal(t, thresholds.Verdict(0.49), Accept)
+	assert.Equal(t, thresholds.Verdict(0.5), Review)
+	assert.Equal(t, thresholds.Verdict(0.95), Reject)
+}
@@ -0,0 +1,29 @@
+package spam
+
+// Verdict is what happens to a scored comment
+type Verdict int
+
+const (
+	Accept Verdict = iota
+	Review
+	Reject
+)
+
+// Thresholds map a score to a verdict, a score at or above a threshold gets its verdict
+type Thresholds struct {
+	Review float64
+	Reject float64
+}
+
+var DefaultThresholds = Thresholds{Review: 0.5, Reject: 0.9}
+
+func (t Thresholds) Verdict(score float64) Verdict {
+	switch {
+	case score >= t.Reject:
+		return Reject
+	case score >= t.Review:
+		return Review
+	default:
+		return Accept
+	}
+}